@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"house-timer/internal/pkg/backup"
+	"house-timer/internal/pkg/remind"
+	"house-timer/pkg/regularity"
+
+	"github.com/pressly/goose/v3"
+	tele "gopkg.in/telebot.v3"
+)
+
+const usage = `Usage: house-timer [command]
+
+Without command the bot is started. Database is DB_PATH (tasks.sqlite by default).
+Only the bot, migrate up and remind run-once without --dry-run apply migrations,
+other commands expect an up to date database.
+
+Commands:
+  migrate up|down|status          manage database migrations
+  backup [file]                   copy database to file while it's in use
+  tasks list [--chat ID]          print tasks of the chat or of all chats
+  remind run-once [--dry-run]     send due reminders once or only print them
+  send [--chat ID] --text TEXT    send message to the chat or to all chats
+`
+
+var ErrUsage = errors.New("bad command, see house-timer help")
+
+func runCommand(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(args[1:])
+	case "backup":
+		return runBackup(ctx, args[1:])
+	case "tasks":
+		return runTasks(ctx, args[1:])
+	case "remind":
+		return runRemind(ctx, args[1:])
+	case "send":
+		return runSend(ctx, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+	return ErrUsage
+}
+
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+func runMigrate(args []string) error {
+	db := openDB()
+	defer db.Close()
+	switch cmd, _ := subcommand(args); cmd {
+	case "up":
+		return goose.Up(db, migrationsDir)
+	case "down":
+		return goose.Down(db, migrationsDir)
+	case "status":
+		return goose.Status(db, migrationsDir)
+	}
+	return ErrUsage
+}
+
+func runBackup(ctx context.Context, args []string) error {
+	path := fmt.Sprintf("tasks-%s.sqlite", time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		path = args[0]
+	}
+	db := openDB()
+	defer db.Close()
+	if err := backup.Backup(ctx, db, path); err != nil {
+		return err
+	}
+	fmt.Println("backup written to", path)
+	return nil
+}
+
+func runTasks(ctx context.Context, args []string) error {
+	cmd, args := subcommand(args)
+	if cmd != "list" {
+		return ErrUsage
+	}
+	flags := flag.NewFlagSet("tasks list", flag.ContinueOnError)
+	chatID := flags.Int64("chat", 0, "chat id, all chats if not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// listing must not change the database
+	a := newApp(openDB(), "")
+	defer a.db.Close()
+	chats := []int64{*chatID}
+	if *chatID == 0 {
+		var err error
+		chats, err = a.taskStorage.GetChatIDs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAT\tID\tNAME\tREGULARITY\tNEXT REMIND")
+	for _, chat := range chats {
+		chatTasks, err := a.taskUsecase.GetTasks(ctx, chat)
+		if err != nil {
+			return err
+		}
+		for _, task := range chatTasks {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", task.ChatID, task.ID, task.Name,
+				regularity.FormatEvery(task.Regularity), task.NextRemind().Format(time.DateTime))
+		}
+	}
+	return w.Flush()
+}
+
+// offlineBot sends messages without polling for updates
+func offlineBot() (*tele.Bot, error) {
+	return tele.NewBot(tele.Settings{
+		Token:   os.Getenv("TOKEN"),
+		Offline: true,
+	})
+}
+
+func runRemind(ctx context.Context, args []string) error {
+	cmd, args := subcommand(args)
+	if cmd != "run-once" {
+		return ErrUsage
+	}
+	flags := flag.NewFlagSet("remind run-once", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print tasks to remind without sending")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	b, err := offlineBot()
+	if err != nil {
+		return err
+	}
+	// a dry run only reads the database
+	openFunc := setupDB
+	if *dryRun {
+		openFunc = openDB
+	}
+	a := newApp(openFunc(), "")
+	defer a.db.Close()
+	r := remind.NewRemindHandler(a.taskStorage, a.taskUsecase, b)
+	if !*dryRun {
+		r.RemindTasks(ctx)
+		return nil
+	}
+	due, err := r.DueTasks(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAT\tID\tNAME\tDUE SINCE")
+	for _, task := range due {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", task.ChatID, task.ID, task.Name, task.NextRemind().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+func runSend(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("send", flag.ContinueOnError)
+	chatID := flags.Int64("chat", 0, "chat id, all chats if not set")
+	text := flags.String("text", "", "message text")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *text == "" {
+		return ErrUsage
+	}
+
+	b, err := offlineBot()
+	if err != nil {
+		return err
+	}
+	a := newApp(openDB(), "")
+	defer a.db.Close()
+	chats := []int64{*chatID}
+	if *chatID == 0 {
+		chats, err = a.taskStorage.GetChatIDs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, chat := range chats {
+		if _, err := b.Send(&tele.Chat{ID: chat}, *text); err != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", chat, err))
+			continue
+		}
+		fmt.Println("sent to", chat)
+	}
+	return errors.Join(errs...)
+}
@@ -6,13 +6,20 @@ import (
 	"embed"
 	"house-timer/internal/pkg/remind"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"house-timer/internal/pkg/backup"
 	"house-timer/internal/pkg/delivery"
 	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/trash"
+	"house-timer/internal/pkg/usecases/calendar"
 	"house-timer/internal/pkg/usecases/tasks"
 
+	"github.com/go-logr/logr"
 	"github.com/pressly/goose/v3"
 	tele "gopkg.in/telebot.v3"
 )
@@ -22,11 +29,20 @@ import (
 //go:embed zz.generated_prod_migrations/*.sql
 var embedMigrations embed.FS
 
-func setupTestDB() *sql.DB {
-	// db, err := sql.Open("sqlite3", ":memory:")
-	db, err := sql.Open("sqlite3", "tasks.sqlite")
+const migrationsDir = "zz.generated_prod_migrations"
+
+// dbPath can be changed with DB_PATH env
+func dbPath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return "tasks.sqlite"
+}
+
+func openDB() *sql.DB {
+	db, err := sql.Open("sqlite3", dbPath())
 	if err != nil {
-		log.Fatalf("Failed to open in-memory SQLite database: %v", err)
+		log.Fatalf("Failed to open SQLite database: %v", err)
 	}
 
 	goose.SetBaseFS(embedMigrations)
@@ -34,14 +50,64 @@ func setupTestDB() *sql.DB {
 	if err := goose.SetDialect("sqlite3"); err != nil {
 		panic(err)
 	}
+	return db
+}
 
-	if err := goose.Up(db, "zz.generated_prod_migrations"); err != nil {
+func setupDB() *sql.DB {
+	db := openDB()
+	if err := goose.Up(db, migrationsDir); err != nil {
 		panic(err)
 	}
 	return db
 }
 
-func main() {
+type app struct {
+	db *sql.DB
+
+	taskStorage     *sqlite_repo.SqliteTaskStorage
+	chatStorage     *sqlite_repo.SqliteChatStorage
+	taskUsecase     *tasks.TaskUsecase
+	calendarUsecase *calendar.CalendarUsecase
+}
+
+func newApp(db *sql.DB, icalURL string) app {
+	taskEventStorage := sqlite_repo.NewSqliteTaskEventStorage(db)
+	taskStorage := sqlite_repo.NewSqliteTaskStorage(db)
+	historyStorage := sqlite_repo.NewSqliteHistoryStorage(db)
+	chatStorage := sqlite_repo.NewSqliteChatStorage(db)
+	return app{
+		db:              db,
+		taskStorage:     taskStorage,
+		chatStorage:     chatStorage,
+		taskUsecase:     tasks.NewTaskUsecase(taskStorage, taskEventStorage, historyStorage, chatStorage),
+		calendarUsecase: calendar.NewCalendarUsecase(taskStorage, chatStorage, icalURL),
+	}
+}
+
+func envInt(name string, def int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// backupConfig reads BACKUP_* env, backups are disabled without BACKUP_DIR
+func backupConfig() backup.Config {
+	interval, err := time.ParseDuration(os.Getenv("BACKUP_INTERVAL"))
+	if err != nil {
+		interval = 24 * time.Hour
+	}
+	return backup.Config{
+		Dir:        os.Getenv("BACKUP_DIR"),
+		Interval:   interval,
+		KeepDaily:  int(envInt("BACKUP_KEEP_DAILY", 7)),
+		KeepWeekly: int(envInt("BACKUP_KEEP_WEEKLY", 4)),
+		AdminID:    envInt("ADMIN_ID", 0),
+	}
+}
+
+func runBot() {
 	pref := tele.Settings{
 		Token:  os.Getenv("TOKEN"),
 		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
@@ -53,14 +119,46 @@ func main() {
 		return
 	}
 
-	db := setupTestDB()
+	// the feed is served on ICAL_ADDR and given to users as ICAL_URL, it's disabled without them
+	icalAddr, icalURL := os.Getenv("ICAL_ADDR"), os.Getenv("ICAL_URL")
+	if icalAddr == "" {
+		icalURL = ""
+	}
+	a := newApp(setupDB(), icalURL)
+	delivery.NewDeliveryHandler(b, a.taskUsecase, a.calendarUsecase)
 
-	taskEventStorage := sqlite_repo.NewSqliteTaskEventStorage(db)
-	taskStorage := sqlite_repo.NewSqliteTaskStorage(db)
-	taskUsecase := tasks.NewTaskUsecase(taskStorage, taskEventStorage)
-	delivery.NewDeliveryHandler(b, taskUsecase)
+	if icalAddr != "" {
+		logger := logr.FromSlogHandler(slog.NewTextHandler(log.Writer(), nil))
+		go func() {
+			// the bot keeps working without the feed, e.g. when the port is taken
+			err := http.ListenAndServe(icalAddr, delivery.NewFeedHandler(a.calendarUsecase, logger))
+			logger.Error(err, "ical feed stopped", "addr", icalAddr)
+		}()
+	}
 
-	r := remind.NewRemindHandler(taskStorage, taskUsecase, b)
+	r := remind.NewRemindHandler(a.taskStorage, a.taskUsecase, b)
 	go r.Start(context.Background())
+
+	// TRASH_RETENTION is how long deleted tasks can be restored
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil {
+		retention = 30 * 24 * time.Hour
+	}
+	go trash.NewPurgeHandler(a.taskUsecase, retention).Start(context.Background())
+
+	if cfg := backupConfig(); cfg.Dir != "" {
+		bh := backup.NewBackupHandler(a.db, cfg, b)
+		go bh.Start(context.Background())
+	}
 	b.Start()
 }
+
+func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	runBot()
+}
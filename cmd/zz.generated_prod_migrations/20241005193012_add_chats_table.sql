@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE Chats (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+    DeletedAt INTEGER,
+
+    ChatID INTEGER NOT NULL UNIQUE,
+
+    ICalToken VARCHAR(64) UNIQUE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS Chats;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD PausedAt INTEGER;
+
+ALTER TABLE Chats
+ADD VacationFrom INTEGER;
+
+ALTER TABLE Chats
+ADD VacationUntil INTEGER;
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN PausedAt;
+ALTER TABLE Chats
+    DROP COLUMN VacationFrom;
+ALTER TABLE Chats
+    DROP COLUMN VacationUntil;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Notes TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE TaskAttachments (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+    DeletedAt INTEGER,
+
+    TaskID INTEGER NOT NULL,
+    FileID TEXT NOT NULL,
+    Kind VARCHAR(16) NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS TaskAttachments;
+ALTER TABLE Tasks
+    DROP COLUMN Notes;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Category TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE Tasks
+ADD Tags TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN Tags;
+ALTER TABLE Tasks
+    DROP COLUMN Category;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE TaskChecklistItems (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+    DeletedAt INTEGER,
+
+    TaskID INTEGER NOT NULL,
+    Position INTEGER NOT NULL,
+    Text TEXT NOT NULL,
+    DoneAt INTEGER
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS TaskChecklistItems;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE Chats
+ADD DigestPeriod TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE Chats
+ADD DigestHour INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD DigestWeekday INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD DigestOnly INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD DigestSentAt INTEGER;
+
+-- +goose Down
+ALTER TABLE Chats
+    DROP COLUMN DigestSentAt;
+ALTER TABLE Chats
+    DROP COLUMN DigestOnly;
+ALTER TABLE Chats
+    DROP COLUMN DigestWeekday;
+ALTER TABLE Chats
+    DROP COLUMN DigestHour;
+ALTER TABLE Chats
+    DROP COLUMN DigestPeriod;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Points INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE TaskHistory
+ADD Points INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE TaskHistory
+    DROP COLUMN Points;
+ALTER TABLE Tasks
+    DROP COLUMN Points;
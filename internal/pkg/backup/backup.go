@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var ErrExists = errors.New("backup file already exists")
+
+var ErrCorrupted = errors.New("backup integrity check failed")
+
+// Backup writes a consistent copy of db to path with VACUUM INTO, db stays usable meanwhile
+func Backup(ctx context.Context, db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return ErrExists
+	}
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// Verify runs PRAGMA integrity_check on the backup
+func Verify(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var res string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&res); err != nil {
+		return errors.Join(ErrCorrupted, err)
+	}
+	if res != "ok" {
+		return errors.Join(ErrCorrupted, fmt.Errorf("integrity check: %s", res))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupVerify(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "tasks.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE Tasks (ID INTEGER PRIMARY KEY, Name VARCHAR(256)); INSERT INTO Tasks(Name) VALUES('test')")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	path := filepath.Join(dir, "backup.sqlite")
+	require.NoError(t, Backup(ctx, db, path))
+	require.ErrorIs(t, Backup(ctx, db, path), ErrExists)
+	require.NoError(t, Verify(ctx, path))
+
+	broken := filepath.Join(dir, "broken.sqlite")
+	require.NoError(t, os.WriteFile(broken, []byte("definitely not a database"), 0o644))
+	require.Error(t, Verify(ctx, broken))
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2024, 10, 20, 12, 0, 0, 0, time.UTC)
+	var files []backupFile
+	// two backups a day for 30 days, newest first
+	for i := 0; i < 60; i++ {
+		files = append(files, backupFile{path: now.Add(-time.Duration(i) * 12 * time.Hour).Format(timeLayout), time: now.Add(-time.Duration(i) * 12 * time.Hour)})
+	}
+	old := expired(files, 7, 4)
+	kept := map[string]bool{}
+	for _, f := range files {
+		kept[f.path] = true
+	}
+	for _, f := range old {
+		delete(kept, f.path)
+	}
+	// 7 days and 4 weeks overlap in the first week
+	require.Len(t, kept, 10)
+	require.True(t, kept[files[0].path])
+	require.False(t, kept[files[1].path])
+	require.True(t, kept[files[2].path])
+}
@@ -0,0 +1,196 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/logmw"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	filePrefix = "tasks-"
+	fileSuffix = ".sqlite"
+	timeLayout = "20060102-150405"
+)
+
+var ErrNoBackups = errors.New("no backups")
+
+type Config struct {
+	Dir      string
+	Interval time.Duration
+	// KeepDaily and KeepWeekly are numbers of last days and weeks to keep one backup of
+	KeepDaily  int
+	KeepWeekly int
+	// AdminID is the only user allowed to get backups with /backup
+	AdminID int64
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+type backupHandler struct {
+	db     *sql.DB
+	cfg    Config
+	logger logr.Logger
+}
+
+func NewBackupHandler(db *sql.DB, cfg Config, bot *tele.Bot) *backupHandler {
+	b := &backupHandler{
+		db:     db,
+		cfg:    cfg,
+		logger: logr.FromSlogHandler(slog.NewTextHandler(log.Writer(), nil)).WithName("backup"),
+	}
+	bot.Handle("/backup", b.handleBackup)
+	return b
+}
+
+func (b *backupHandler) handleBackup(c tele.Context) error {
+	log := logmw.GetLogger(c)
+	if b.cfg.AdminID == 0 || c.Sender() == nil || c.Sender().ID != b.cfg.AdminID {
+		return c.Send("Эта команда только для администратора")
+	}
+	latest, err := b.Latest()
+	if errors.Is(err, ErrNoBackups) {
+		latest, err = b.RunOnce(context.Background())
+	}
+	if err != nil {
+		log.Error(err, "failed to get backup")
+		return c.Send("Не получилось достать бэкап, почитай логи")
+	}
+	return c.Send(&tele.Document{
+		File:     tele.FromDisk(latest),
+		FileName: filepath.Base(latest),
+		Caption:  "Последний бэкап",
+	})
+}
+
+func (b *backupHandler) list() ([]backupFile, error) {
+	entries, err := os.ReadDir(b.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var res []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		t, err := time.ParseInLocation(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix), time.UTC)
+		if err != nil {
+			continue
+		}
+		res = append(res, backupFile{path: filepath.Join(b.cfg.Dir, name), time: t})
+	}
+	// newest first
+	slices.SortFunc(res, func(a, b backupFile) int {
+		return b.time.Compare(a.time)
+	})
+	return res, nil
+}
+
+// Latest returns path to the newest backup
+func (b *backupHandler) Latest() (string, error) {
+	files, err := b.list()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", ErrNoBackups
+	}
+	return files[0].path, nil
+}
+
+// expired returns backups not needed to keep one per each of keepDaily last days
+// and keepWeekly last weeks, files must be sorted newest first
+func expired(files []backupFile, keepDaily int, keepWeekly int) []backupFile {
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	var res []backupFile
+	for i, f := range files {
+		day := f.time.Format(time.DateOnly)
+		year, week := f.time.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		keep := i == 0
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep = true
+		}
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep = true
+		}
+		if !keep {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// RunOnce writes a new verified backup and removes expired ones
+func (b *backupHandler) RunOnce(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(b.cfg.Dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(b.cfg.Dir, filePrefix+time.Now().UTC().Format(timeLayout)+fileSuffix)
+	if err := Backup(ctx, b.db, path); err != nil {
+		return "", err
+	}
+	if err := Verify(ctx, path); err != nil {
+		return "", errors.Join(err, os.Remove(path))
+	}
+
+	files, err := b.list()
+	if err != nil {
+		return "", err
+	}
+	for _, f := range expired(files, b.cfg.KeepDaily, b.cfg.KeepWeekly) {
+		if err := os.Remove(f.path); err != nil {
+			return "", err
+		}
+		b.logger.Info("removed expired backup", "path", f.path)
+	}
+	return path, nil
+}
+
+func (b *backupHandler) backupIfDue(ctx context.Context) {
+	latest, err := b.list()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		b.logger.Error(err, "failed to list backups")
+		return
+	}
+	if len(latest) > 0 && time.Since(latest[0].time) < b.cfg.Interval {
+		return
+	}
+	path, err := b.RunOnce(ctx)
+	if err != nil {
+		b.logger.Error(err, "failed to backup")
+		return
+	}
+	b.logger.Info("backup written", "path", path)
+}
+
+func (b *backupHandler) Start(ctx context.Context) {
+	b.backupIfDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+			b.backupIfDue(ctx)
+		}
+	}
+}
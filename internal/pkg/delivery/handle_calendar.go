@@ -0,0 +1,85 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/usecases/calendar"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const icalFileName = "house-timer.ics"
+
+func (dh deliveryHandler) handleICal(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	data, err := dh.calendarUsecase.ExportCalendar(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to export calendar")
+		return c.Send(internalError)
+	}
+	caption := "Откройте файл, чтобы добавить задачи в календарь"
+	feedURL, err := dh.calendarUsecase.FeedURL(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get feed url")
+		return c.Send(internalError)
+	}
+	if feedURL != "" {
+		caption += "\nИли подпишитесь, чтобы календарь обновлялся сам: " + feedURL
+	}
+	return c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: icalFileName,
+		MIME:     "text/calendar",
+		Caption:  caption,
+	})
+}
+
+type feedHandler struct {
+	calendarUsecase entities.CalendarUsecase
+	logger          logr.Logger
+}
+
+// NewFeedHandler serves chat calendars at /ical/<token>.ics
+func NewFeedHandler(calendarUsecase entities.CalendarUsecase, logger logr.Logger) http.Handler {
+	fh := feedHandler{
+		calendarUsecase: calendarUsecase,
+		logger:          logger.WithName("feed"),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ical/{file}", fh.handleFeed)
+	return mux
+}
+
+func (fh feedHandler) handleFeed(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := fh.calendarUsecase.ExportFeed(logr.NewContext(r.Context(), fh.logger), token)
+	if err != nil {
+		if errors.Is(err, calendar.ErrNoFeed) {
+			http.NotFound(w, r)
+			return
+		}
+		fh.logger.Error(err, "failed to export feed")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+icalFileName+`"`)
+	_, err = w.Write(data)
+	if err != nil {
+		fh.logger.Error(err, "failed to write feed")
+	}
+}
@@ -0,0 +1,170 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const taskKindQuestion = "Повторять или один раз?"
+
+const categoryExamples = "Можно выбрать или написать свою категорию и добавить теги, например: Кухня #дети"
+
+// noCategoryData is the button data to skip the category
+const noCategoryData = "-"
+
+// maxCallbackData is the telegram limit of button data in bytes
+const maxCallbackData = 64
+
+func (dh deliveryHandler) askCategory(c tele.Context, ctx context.Context, chatID int64) error {
+	log := logmw.GetLogger(c)
+	categories, err := dh.taskUsecase.GetCategories(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get categories")
+		return c.Send(internalError)
+	}
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	var row tele.Row
+	for _, category := range categories {
+		// unique and data are sent together separated by "|"
+		if len(dh.btnTaskCategory.Unique)+len(category)+1 > maxCallbackData {
+			continue
+		}
+		row = append(row, menu.Data(category, dh.btnTaskCategory.Unique, category))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		menu.Row(menu.Data("Без категории", dh.btnTaskCategory.Unique, noCategoryData)),
+		menu.Row(menu.Data("Галя, отмена!", "taskCreateStop")),
+	)
+	menu.Inline(rows...)
+	return c.Send("В какую категорию ее положить? "+categoryExamples, menu)
+}
+
+func (dh deliveryHandler) handleKindCategory(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskCategoryChoice(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to start category choice")
+		return c.Send(internalError)
+	}
+	return dh.askCategory(c, ctx, chatID)
+}
+
+func (dh deliveryHandler) handleChooseCategory(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	category := c.Data()
+	if category == noCategoryData {
+		category = ""
+	}
+	err := dh.taskUsecase.ChooseTaskCategory(ctx, chatID, category)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to choose task category")
+		return c.Send(internalError)
+	}
+	return c.Send(taskKindQuestion, dh.taskKindMenu)
+}
+
+func (dh deliveryHandler) handleEditCategory(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskCategoryEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start category edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	return c.Send(formatLabels(task)+". Напишите новую категорию и теги, например: Кухня #дети. "+
+		"Чтобы убрать категорию, напишите «-»", dh.taskEditMenuGoBack)
+}
+
+// handleList shows all tasks or the ones in category or with tag: /list кухня, /list #дети
+func (dh deliveryHandler) handleList(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get tasks")
+		return c.Send(internalError)
+	}
+	if len(chatTasks) == 0 {
+		return c.Send("У вас нет задач", dh.mainMenu)
+	}
+	filter := strings.TrimSpace(c.Message().Payload)
+	if filter == "" {
+		return c.Send(formatTasks(chatTasks), dh.mainMenu)
+	}
+	res := formatFilteredTasks(chatTasks, filter)
+	if res == "" {
+		return c.Send("Нет задач с категорией или тегом «"+filter+"»", dh.mainMenu)
+	}
+	return c.Send(res, dh.mainMenu)
+}
+
+// formatFilteredTasks lists tasks with the label keeping their numbers in the full list
+func formatFilteredTasks(chatTasks []entities.UserTask, label string) string {
+	var indexes []int
+	for i, task := range chatTasks {
+		if task.HasLabel(label) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return ""
+	}
+	return "Задачи «" + label + "»:\n" + formatTaskLines(chatTasks, indexes)
+}
+
+func formatLabels(task entities.UserTask) string {
+	res := "Категория: "
+	if task.Category == "" {
+		res += "нет"
+	} else {
+		res += task.Category
+	}
+	if len(task.Tags) > 0 {
+		res += ", теги: #" + strings.Join(task.Tags, " #")
+	}
+	return res
+}
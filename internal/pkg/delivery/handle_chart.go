@@ -0,0 +1,52 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+// paletteMarks match chart.Palette colors in the caption
+var paletteMarks = []string{"🟦", "🟧", "🟩", "🟥", "🟪", "🟨"}
+
+func chartCaption(c entities.Chart) string {
+	var b strings.Builder
+	if c.Task.ID != 0 {
+		fmt.Fprintf(&b, "«%s»: серые засечки — сроки, 🟢 — выполнено вовремя, 🔴 — с опозданием\n", c.Task.Name)
+	}
+	if len(c.Members) == 0 {
+		b.WriteString("За полгода ничего не выполнено")
+		return b.String()
+	}
+	b.WriteString("Выполнено по месяцам:")
+	for i, member := range c.Members {
+		fmt.Fprintf(&b, " %s %s", paletteMarks[i%len(paletteMarks)], memberName(member))
+	}
+	return b.String()
+}
+
+func (dh deliveryHandler) handleChart(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	res, err := dh.taskUsecase.TaskChart(ctx, chatID, payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrUnknownTask) || errors.Is(err, tasks.ErrBadTaskNumber) {
+			return c.Send("Не нашел такую задачу, напишите /chart <номер или название> или /chart для всех задач")
+		}
+		log.Error(err, "failed to make chart")
+		return c.Send(internalError)
+	}
+	return c.Send(&tele.Photo{File: tele.FromReader(bytes.NewReader(res.Image)), Caption: chartCaption(res)})
+}
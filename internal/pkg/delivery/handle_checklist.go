@@ -0,0 +1,57 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const checklistQuestion = "Напишите пункты чеклиста, каждый с новой строки. " +
+	"В напоминании их можно будет отмечать, когда все отмечены, задача выполнена. " +
+	"Чтобы удалить чеклист, напишите «очистить»"
+
+func formatChecklist(items []entities.ChecklistItem) string {
+	res := ""
+	for i, item := range items {
+		res += fmt.Sprintf("%d. %s\n", i+1, item.Text)
+	}
+	return res
+}
+
+func (dh deliveryHandler) handleEditChecklist(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskChecklistEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start checklist edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	items, err := dh.taskUsecase.GetTaskChecklist(ctx, task.ID)
+	if err != nil {
+		log.Error(err, "failed to get checklist")
+		return c.Send(internalError)
+	}
+	if len(items) == 0 {
+		return c.Send(checklistQuestion, dh.taskEditMenuGoBack)
+	}
+	return c.Send("Сейчас в чеклисте:\n"+formatChecklist(items)+"Новый список заменит текущий. "+checklistQuestion, dh.taskEditMenuGoBack)
+}
@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const thresholdQuestion = "Через сколько напомнить? Например: 5000 км или 300 литров"
+
+const fallbackQuestion = "А если столько не наберется, через какое время все равно напомнить? Например: 6 месяцев, раз в год"
+
+const logUsage = "Напишите /log <номер или название> <сколько>, например /log 2 120 или /log поменять фильтр 120"
+
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " " + unit
+}
+
+func (dh deliveryHandler) handleKindCounter(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.ChooseCounterKind(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to choose counter kind")
+		return c.Send(internalError)
+	}
+	return c.Send(thresholdQuestion, dh.taskCreateStopMenu)
+}
+
+// handleLog adds usage to a counter task: /log поменять фильтр 120
+func (dh deliveryHandler) handleLog(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		return c.Send(logUsage)
+	}
+	task, predicted, err := dh.taskUsecase.LogCounter(ctx, chatID, payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrUnknownTask) || errors.Is(err, tasks.ErrBadTaskNumber) {
+			return c.Send("Не нашел такую задачу. " + logUsage)
+		} else if errors.Is(err, tasks.ErrNotCounterTask) {
+			return c.Send("Эта задача напоминается по времени, а не по счетчику")
+		} else if errors.Is(err, tasks.ErrParseAmount) {
+			return c.Send("Не понял, сколько записать. " + logUsage)
+		}
+		log.Error(err, "failed to log counter")
+		return c.Send(internalError)
+	}
+	res := fmt.Sprintf("«%s»: %s из %s%s", task.Name,
+		formatAmount(task.Counter), formatAmount(task.Threshold), unitSuffix(task.CounterUnit))
+	switch {
+	case task.CounterReached():
+		res += ", пора!"
+	case !predicted.IsZero():
+		res += ", при таком темпе напомню примерно " + predicted.Format("02.01.2006")
+	}
+	return c.Send(res)
+}
@@ -0,0 +1,77 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+	"house-timer/pkg/regularity"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const dependencyQuestion = "После какой задачи и через сколько напомнить об этой? " +
+	"Например: 3 через 1 день или «Разморозить морозилку через 2 дня». Чтобы убрать зависимость, напишите «нет»"
+
+func formatDelay(delay time.Duration) string {
+	if delay <= 0 {
+		return "сразу"
+	}
+	return "через " + regularity.Format(delay)
+}
+
+// formatChain shows tasks done one after another: "Разморозить морозилку → через 1 день → Помыть морозилку"
+func formatChain(chain []entities.UserTask) string {
+	var parts []string
+	for i, task := range chain {
+		if i > 0 {
+			parts = append(parts, formatDelay(task.AfterDelay))
+		}
+		parts = append(parts, "«"+task.Name+"»")
+	}
+	return strings.Join(parts, " → ")
+}
+
+// sendEditMenu sends the edit menu with the chain of the edited task if it has one
+func (dh deliveryHandler) sendEditMenu(c tele.Context, chatID int64, prefix string) error {
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	chain, err := dh.taskUsecase.GetTaskChain(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get task chain")
+		return c.Send(internalError)
+	}
+	if len(chain) > 1 {
+		prefix += fmt.Sprintf("Цепочка: %s\n", formatChain(chain))
+	}
+	return c.Send(prefix+"Выберите действие", dh.taskEditMenu)
+}
+
+func (dh deliveryHandler) handleEditDependency(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskDependencyEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start dependency edit")
+		return c.Send(internalError)
+	}
+	chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get tasks")
+		return c.Send(internalError)
+	}
+	return c.Send(formatTasks(chatTasks)+dependencyQuestion, dh.taskEditMenuGoBack)
+}
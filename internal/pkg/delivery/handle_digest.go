@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const digestUsage = "Сводка по просроченным, ближайшим и сделанным задачам: " +
+	"/digest каждый день в 9 или /digest по понедельникам в 10 (по часовому поясу чата, его задаёт /quiet). " +
+	"Добавьте «только», чтобы присылать сводку вместо отдельных напоминаний, выключить: /digest выкл"
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "по понедельникам",
+	time.Tuesday:   "по вторникам",
+	time.Wednesday: "по средам",
+	time.Thursday:  "по четвергам",
+	time.Friday:    "по пятницам",
+	time.Saturday:  "по субботам",
+	time.Sunday:    "по воскресеньям",
+}
+
+func formatDigestSettings(settings entities.DigestSettings, offset time.Duration) string {
+	if settings.Period == entities.DigestOff {
+		return "Сводка выключена"
+	}
+	when := "каждый день"
+	if settings.Period == entities.DigestWeekly {
+		when = weekdayNames[settings.Weekday]
+	}
+	res := fmt.Sprintf("Присылаю сводку %s в %02d:00 (%s)", when, settings.Hour, formatOffset(offset))
+	if settings.Only {
+		res += " вместо отдельных напоминаний"
+	}
+	return res
+}
+
+func (dh deliveryHandler) handleDigest(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		chat, err := dh.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat")
+			return c.Send(internalError)
+		}
+		return c.Send(formatDigestSettings(chat.Digest, chat.Quiet.Offset) + "\n" + digestUsage)
+	}
+
+	settings, err := dh.taskUsecase.SetDigest(ctx, chatID, payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrParseDigest) {
+			return c.Send("Не понял, когда присылать сводку. " + digestUsage)
+		}
+		log.Error(err, "failed to set digest")
+		return c.Send(internalError)
+	}
+	chat, err := dh.taskUsecase.GetChat(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get chat")
+		return c.Send(internalError)
+	}
+	return c.Send(formatDigestSettings(settings, chat.Quiet.Offset), dh.mainMenu)
+}
@@ -0,0 +1,75 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const doneDateExamples = "Например: сейчас, вчера, 3 дня назад, 12.10 или вчера в 18:30"
+
+const doneDateError = "Не понял, когда это было. " + doneDateExamples
+
+const dateError = "Не понял дату, попробуйте еще раз. Подойдет, например, 15.11, 15 ноября, вчера или через неделю"
+
+func (dh deliveryHandler) handleMarkDone(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskDone(ctx, chatID, senderMember(c))
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		} else if errors.Is(err, entities.ErrProofRequired) {
+			return c.Send(proofRequired, dh.taskEditMenu)
+		}
+		log.Error(err, "failed to start task done")
+		return c.Send(internalError)
+	}
+	return c.Send("Когда задача была выполнена? "+doneDateExamples, dh.taskEditMenuGoBack)
+}
+
+func (dh deliveryHandler) handleDone(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get tasks")
+			return c.Send(internalError)
+		}
+		if len(chatTasks) == 0 {
+			return c.Send("У вас нет задач", dh.mainMenu)
+		}
+		return c.Send(formatTasks(chatTasks) + "Напишите /done <номер или название> [когда], например /done 2 вчера")
+	}
+
+	task, doneAt, err := dh.taskUsecase.DoneTask(ctx, chatID, senderMember(c), payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrUnknownTask) || errors.Is(err, tasks.ErrBadTaskNumber) {
+			return c.Send("Не нашел такую задачу, напишите ее номер или название")
+		} else if errors.Is(err, tasks.ErrParseDate) {
+			return c.Send(doneDateError)
+		} else if errors.Is(err, entities.ErrProofRequired) {
+			return c.Send(proofRequired)
+		}
+		log.Error(err, "failed to mark task done")
+		return c.Send(internalError)
+	}
+	return c.Send(fmt.Sprintf("«%s» выполнена %s, следующее напоминание %s",
+		task.Name, doneAt.Format("02.01 15:04"), task.NextRemind().Format("02.01")))
+}
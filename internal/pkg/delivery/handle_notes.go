@@ -0,0 +1,125 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const notesQuestion = "Напишите заметку к задаче и пришлите фото или файлы, они придут вместе с напоминанием. " +
+	"Чтобы удалить заметку и вложения, напишите «очистить»"
+
+func (dh deliveryHandler) handleEditNotes(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskNotesEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start notes edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	attachments, err := dh.taskUsecase.GetTaskAttachments(ctx, task.ID)
+	if err != nil {
+		log.Error(err, "failed to get attachments")
+		return c.Send(internalError)
+	}
+	if task.Notes == "" && len(attachments) == 0 {
+		return c.Send(notesQuestion, dh.notesMenu)
+	}
+	err = dh.sendNotes(c, task, attachments)
+	if err != nil {
+		log.Error(err, "failed to send notes")
+		return c.Send(internalError)
+	}
+	return c.Send("Новая заметка заменит текущую, новые файлы добавятся к уже прикрепленным. "+notesQuestion, dh.notesMenu)
+}
+
+func (dh deliveryHandler) handleNotesDone(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.FinishTaskNotesEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать заметки", dh.mainMenu)
+		}
+		log.Error(err, "failed to finish notes edit")
+		return c.Send(internalError)
+	}
+	return c.Send("Выберите действие", dh.taskEditMenu)
+}
+
+// handlePhoto takes the photo as completion proof while a reminder waits for it,
+// otherwise attaches it to the edited task
+func (dh deliveryHandler) handlePhoto(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	eventType, err := dh.taskUsecase.CurrentEventType(ctx, chatID)
+	if err != nil && !errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+		log.Error(err, "failed to get current event type")
+		return c.Send(internalError)
+	}
+	if eventType == entities.TaskRemindEvent {
+		return dh.completeWithProof(c, c.Message().Photo.FileID)
+	}
+	return dh.addAttachment(c, c.Message().Photo.FileID, entities.AttachmentPhoto)
+}
+
+func (dh deliveryHandler) addAttachment(c tele.Context, fileID string, kind entities.AttachmentKind) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.AddTaskAttachment(ctx, chatID, fileID, kind)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) || errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Чтобы прикрепить файл к задаче, откройте ее заметки в меню изменения задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to add attachment")
+		return c.Send(internalError)
+	}
+	return c.Send("Прикрепил, можно прислать еще", dh.notesMenu)
+}
+
+// sendNotes sends notes of the task followed by its attachments
+func (dh deliveryHandler) sendNotes(c tele.Context, task entities.UserTask, attachments []entities.Attachment) error {
+	if task.Notes != "" {
+		if err := c.Send("Заметка: " + task.Notes); err != nil {
+			return err
+		}
+	}
+	for _, attachment := range attachments {
+		if err := c.Send(attachmentMessage(attachment)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachmentMessage makes a sendable message of the stored attachment
+func attachmentMessage(attachment entities.Attachment) tele.Sendable {
+	file := tele.File{FileID: attachment.FileID}
+	if attachment.Kind == entities.AttachmentPhoto {
+		return &tele.Photo{File: file}
+	}
+	return &tele.Document{File: file}
+}
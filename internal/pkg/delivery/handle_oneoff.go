@@ -0,0 +1,73 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const regularityQuestion = "Как часто о ней надо напоминать?\n Например: 10 дней, каждые 2 недели, раз в месяц"
+
+const dueDateQuestion = "Когда напомнить? Например: 15.11, 15 ноября или через 2 недели"
+
+const dueDateGuess = "Вы имели в виду %s? Напишите «да» или другую дату"
+
+func (dh deliveryHandler) chooseTaskKind(c tele.Context, oneOff bool) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.ChooseTaskKind(ctx, chatID, oneOff)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to choose task kind")
+		return c.Send(internalError)
+	}
+	if oneOff {
+		return c.Send(dueDateQuestion, dh.taskCreateStopMenu)
+	}
+	return c.Send(regularityQuestion, dh.taskCreateStopMenu)
+}
+
+func (dh deliveryHandler) handleKindRepeat(c tele.Context) error {
+	return dh.chooseTaskKind(c, false)
+}
+
+func (dh deliveryHandler) handleKindOnce(c tele.Context) error {
+	return dh.chooseTaskKind(c, true)
+}
+
+func (dh deliveryHandler) handleEditDueDate(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskDueDateEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start due date edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	if task.OneOff {
+		return c.Send(dueDateQuestion, dh.taskEditMenuGoBack)
+	}
+	return c.Send("До какого дня задачу нужно сделать в этот раз? Например: 15.11 или через неделю. "+
+		"Чтобы убрать срок, напишите «нет»", dh.taskEditMenuGoBack)
+}
@@ -0,0 +1,99 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+	"house-timer/pkg/regularity"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const vacationUsage = "Напишите, когда вернетесь: /vacation 25.10 или /vacation 2 недели, " +
+	"закончить отпуск раньше: /vacation стоп"
+
+var vacationStops = map[string]bool{
+	"стоп":   true,
+	"конец":  true,
+	"всё":    true,
+	"все":    true,
+	"off":    true,
+	"stop":   true,
+	"отмена": true,
+}
+
+func (dh deliveryHandler) handleTogglePause(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	task, err := dh.taskUsecase.ToggleTaskPause(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to toggle task pause")
+		return c.Send(internalError)
+	}
+	if task.Paused() {
+		return c.Send(fmt.Sprintf("«%s» на паузе, напоминать не буду, выберите действие", task.Name), dh.taskEditMenu)
+	}
+	return c.Send(fmt.Sprintf("«%s» снова в деле, следующее напоминание %s, выберите действие",
+		task.Name, task.NextRemind().Format("02.01")), dh.taskEditMenu)
+}
+
+func (dh deliveryHandler) handleVacation(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		chat, err := dh.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat")
+			return c.Send(internalError)
+		}
+		if chat.OnVacation(time.Now()) {
+			return c.Send(fmt.Sprintf("Вы в отпуске до %s\n%s", chat.VacationUntil.Format("02.01.2006"), vacationUsage))
+		}
+		return c.Send(vacationUsage)
+	}
+
+	if vacationStops[strings.ToLower(payload)] {
+		shift, err := dh.taskUsecase.EndVacation(ctx, chatID)
+		if err != nil {
+			if errors.Is(err, tasks.ErrNoVacation) {
+				return c.Send("Вы и так не в отпуске", dh.mainMenu)
+			}
+			log.Error(err, "failed to end vacation")
+			return c.Send(internalError)
+		}
+		chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get tasks")
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf("С возвращением! Сроки задач сдвинуты на %s\n%s",
+			regularity.Format(shift), formatTasks(chatTasks)), dh.mainMenu)
+	}
+
+	until, err := dh.taskUsecase.StartVacation(ctx, chatID, payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrParseVacation) {
+			return c.Send("Не понял, когда вы вернетесь. " + vacationUsage)
+		}
+		log.Error(err, "failed to start vacation")
+		return c.Send(internalError)
+	}
+	return c.Send(fmt.Sprintf("Хорошего отдыха! Не напоминаю до %s, потом сдвину сроки задач на время отпуска",
+		until.Format("02.01.2006")), dh.mainMenu)
+}
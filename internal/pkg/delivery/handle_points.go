@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+// senderMember returns the user who sent the update, points and history are recorded for them
+func senderMember(c tele.Context) entities.Member {
+	sender := c.Sender()
+	if sender == nil {
+		return entities.Member{}
+	}
+	return entities.NewMember(sender.ID, sender.FirstName, sender.LastName, sender.Username)
+}
+
+const pointsQuestion = "Сколько баллов давать за выполнение? Напишите число от 1 до 100 " +
+	"или «авто», чтобы считать баллы по регулярности"
+
+var leaderboardMedals = []string{"🥇", "🥈", "🥉"}
+
+func formatPoints(task entities.UserTask) string {
+	if task.Points > 0 {
+		return fmt.Sprintf("%d", task.Points)
+	}
+	return fmt.Sprintf("%d (авто)", task.DefaultPoints())
+}
+
+func formatRanking(ranking []entities.MemberPoints) string {
+	if len(ranking) == 0 {
+		return "пока никто не набрал баллов\n"
+	}
+	var b strings.Builder
+	for i, member := range ranking {
+		place := fmt.Sprintf("%d.", i+1)
+		if i < len(leaderboardMedals) {
+			place = leaderboardMedals[i]
+		}
+		fmt.Fprintf(&b, "%s %s — %d\n", place, memberName(member.Member), member.Points)
+	}
+	return b.String()
+}
+
+func formatLeaderboard(board entities.Leaderboard) string {
+	return "За неделю:\n" + formatRanking(board.Week) + "\nЗа все время:\n" + formatRanking(board.AllTime)
+}
+
+func (dh deliveryHandler) handleEditPoints(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskPointsEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start points edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	return c.Send(fmt.Sprintf("Сейчас за задачу %s баллов. ", formatPoints(task))+pointsQuestion, dh.taskEditMenuGoBack)
+}
+
+func (dh deliveryHandler) handleLeaderboard(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	board, err := dh.taskUsecase.GetLeaderboard(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get leaderboard")
+		return c.Send(internalError)
+	}
+	return c.Send(formatLeaderboard(board))
+}
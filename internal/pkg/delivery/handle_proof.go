@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const proofRequired = "Эта задача выполняется только с фото: нажмите «Задача выполнена» в напоминании и пришлите фото"
+
+func (dh deliveryHandler) completeWithProof(c tele.Context, fileID string) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.CompleteTaskWithProof(ctx, chatID, senderMember(c), fileID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Чтобы отправить фото выполнения, сначала нажмите «Задача выполнена» в напоминании")
+		}
+		log.Error(err, "failed to complete task with proof")
+		return c.Send(internalError)
+	}
+	return c.Send("Фото принято, молодец огурец")
+}
+
+func (dh deliveryHandler) handleToggleProof(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	task, err := dh.taskUsecase.ToggleTaskProof(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to toggle task proof")
+		return c.Send(internalError)
+	}
+	if task.RequiresProof {
+		return c.Send(fmt.Sprintf("Теперь «%s» можно отметить выполненной только с фото, выберите действие", task.Name), dh.taskEditMenu)
+	}
+	return c.Send(fmt.Sprintf("Фото для «%s» больше не нужно, выберите действие", task.Name), dh.taskEditMenu)
+}
+
+func (dh deliveryHandler) handleShowProofs(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	proofs, err := dh.taskUsecase.GetTaskProofs(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to get task proofs")
+		return c.Send(internalError)
+	}
+	if len(proofs) == 0 {
+		return c.Send("Фото выполнения пока нет, выберите действие", dh.taskEditMenu)
+	}
+	for _, proof := range proofs {
+		photo := &tele.Photo{
+			File:    tele.File{FileID: proof.ProofFileID},
+			Caption: "Выполнено " + proof.DoneAt.Format("02.01.2006 15:04"),
+		}
+		if err := c.Send(photo); err != nil {
+			log.Error(err, "failed to send proof", "recordID", proof.ID)
+			return c.Send(internalError)
+		}
+	}
+	return c.Send("Выберите действие", dh.taskEditMenu)
+}
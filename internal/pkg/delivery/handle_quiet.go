@@ -0,0 +1,73 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const quietUsage = "Ночью не напоминаю, если задать тихие часы: /quiet 22-8, " +
+	"в выходные можно дольше: /quiet 23-8 выходные до 11. Часовой пояс: /quiet utc+3 или /quiet мск, " +
+	"выключить: /quiet выкл"
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func formatOffset(offset time.Duration) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	if offset%time.Hour == 0 {
+		return fmt.Sprintf("UTC%s%d", sign, int(offset.Hours()))
+	}
+	return "UTC" + sign + formatClock(offset)
+}
+
+func formatQuiet(quiet entities.QuietHours) string {
+	if !quiet.Enabled() {
+		return fmt.Sprintf("Тихие часы выключены, часовой пояс %s", formatOffset(quiet.Offset))
+	}
+	res := fmt.Sprintf("Тихие часы с %s до %s", formatClock(quiet.From), formatClock(quiet.Until))
+	if quiet.WeekendUntil != 0 {
+		res += fmt.Sprintf(", в выходные до %s", formatClock(quiet.WeekendUntil))
+	}
+	return res + fmt.Sprintf(" (%s), напоминания в это время придут после", formatOffset(quiet.Offset))
+}
+
+func (dh deliveryHandler) handleQuiet(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		chat, err := dh.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat")
+			return c.Send(internalError)
+		}
+		return c.Send(formatQuiet(chat.Quiet) + "\n" + quietUsage)
+	}
+
+	quiet, err := dh.taskUsecase.SetQuietHours(ctx, chatID, payload)
+	if err != nil {
+		if errors.Is(err, tasks.ErrParseQuiet) {
+			return c.Send("Не понял, когда не беспокоить. " + quietUsage)
+		}
+		log.Error(err, "failed to set quiet hours")
+		return c.Send(internalError)
+	}
+	return c.Send(formatQuiet(quiet), dh.mainMenu)
+}
@@ -0,0 +1,44 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+func (dh deliveryHandler) handleToggleAnchor(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	anchor, err := dh.taskUsecase.ToggleTaskAnchor(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrOneOffTask) {
+			return c.Send("У разовой задачи нет расписания, выберите действие", dh.taskEditMenu)
+		}
+		log.Error(err, "failed to toggle task anchor")
+		return c.Send(internalError)
+	}
+	if anchor == entities.AnchorFixed {
+		return c.Send("Буду напоминать по расписанию, не сдвигая его, если задачу сделали позже. "+
+			"С какого дня считать? Например: сегодня, 01.10 или 25.10", dh.taskEditMenuGoBack)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	return c.Send(fmt.Sprintf("Теперь считаю от последнего выполнения, следующее напоминание %s, выберите действие",
+		task.NextRemind().Format("02.01")), dh.taskEditMenu)
+}
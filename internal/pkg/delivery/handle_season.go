@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/usecases/tasks"
+	"house-timer/pkg/regularity"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+const seasonQuestion = "В какое время года напоминать о задаче? " +
+	"Например: «с апреля по октябрь», «1 декабря - 15 января» или несколько через запятую. " +
+	"Чтобы напоминать круглый год, напишите «круглый год»"
+
+// formatSeason shows windows of the task: "01.04–31.10, 01.12–15.01"
+func formatSeason(season []regularity.Window) string {
+	parts := make([]string, 0, len(season))
+	for _, w := range season {
+		parts = append(parts, fmt.Sprintf("%02d.%02d–%02d.%02d", w.FromDay, w.FromMonth, w.ToDay, w.ToMonth))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSeasonStatus is shown in the task list for tasks with a season
+func formatSeasonStatus(task entities.UserTask, now time.Time) string {
+	if len(task.Season) == 0 {
+		return ""
+	}
+	if task.InSeason(now) {
+		return ", сезон " + formatSeason(task.Season)
+	}
+	return fmt.Sprintf(", не сезон до %s", task.NextDue().Format("02.01"))
+}
+
+func (dh deliveryHandler) handleEditSeason(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.StartTaskSeasonEdit(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to start season edit")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	current := "Сейчас напоминаю круглый год. "
+	if len(task.Season) > 0 {
+		current = fmt.Sprintf("Сейчас напоминаю %s. ", formatSeason(task.Season))
+	}
+	return c.Send(current+seasonQuestion, dh.taskEditMenuGoBack)
+}
@@ -0,0 +1,119 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+// statsNameWidth keeps the table narrow enough for phones
+const statsNameWidth = 18
+
+// maxSnoozedTasks is how many of the most snoozed tasks are shown
+const maxSnoozedTasks = 3
+
+func shortName(name string) string {
+	runes := []rune(name)
+	if len(runes) <= statsNameWidth {
+		return name
+	}
+	return string(runes[:statsNameWidth-1]) + "…"
+}
+
+func formatStatsDelay(delay time.Duration) string {
+	days := int(math.Round(delay.Hours() / 24))
+	if days == 0 {
+		return "0д"
+	}
+	return fmt.Sprintf("%+dд", days)
+}
+
+func memberName(member entities.Member) string {
+	if member.Name != "" {
+		return member.Name
+	}
+	if member.ID == 0 {
+		return "Кто-то"
+	}
+	return fmt.Sprintf("id%d", member.ID)
+}
+
+// formatStats renders statistics as monospace tables for html parse mode
+func formatStats(stats entities.ChatStats) string {
+	if stats.Completed+stats.Skipped+stats.Snoozed == 0 {
+		return "Пока нечего считать: ни одна задача еще не выполнена"
+	}
+	var b, table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "Задача\tВовремя\tСдвиг\tСерия")
+	for _, task := range stats.Tasks {
+		onTime, delay, streak := "—", "—", "—"
+		if task.Rated > 0 {
+			onTime = fmt.Sprintf("%d/%d", task.OnTime, task.Rated)
+			delay = formatStatsDelay(task.AvgDelay)
+			streak = fmt.Sprintf("%d (%d)", task.CurrentStreak, task.BestStreak)
+		} else if task.Completed > 0 {
+			onTime = fmt.Sprintf("%d раз", task.Completed)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", shortName(task.Task.Name), onTime, delay, streak)
+	}
+	w.Flush()
+	// escaped after aligning so entities do not break the columns
+	b.WriteString("<b>Статистика</b>\n<pre>" + html.EscapeString(table.String()) + "</pre>")
+	b.WriteString("Вовремя — выполнено в срок из всех повторов, сдвиг — в среднем позже (+) или раньше (−) срока, " +
+		"серия — сейчас (лучшая)\n\n")
+	fmt.Fprintf(&b, "Выполнено: %d, пропущено: %d, отложено: %d\n", stats.Completed, stats.Skipped, stats.Snoozed)
+
+	snoozed := make([]entities.TaskStats, 0, len(stats.Tasks))
+	for _, task := range stats.Tasks {
+		if task.Snoozed > 0 {
+			snoozed = append(snoozed, task)
+		}
+	}
+	sort.SliceStable(snoozed, func(i, j int) bool {
+		return snoozed[i].Snoozed > snoozed[j].Snoozed
+	})
+	if len(snoozed) > 0 {
+		var parts []string
+		for _, task := range snoozed[:min(len(snoozed), maxSnoozedTasks)] {
+			parts = append(parts, fmt.Sprintf("%s (%d)", html.EscapeString(task.Task.Name), task.Snoozed))
+		}
+		b.WriteString("Чаще всего откладываете: " + strings.Join(parts, ", ") + "\n")
+	}
+
+	// shares make sense only when chores are split between several members
+	if len(stats.Members) > 1 {
+		table.Reset()
+		w = tabwriter.NewWriter(&table, 0, 0, 1, ' ', tabwriter.AlignRight)
+		for _, member := range stats.Members {
+			fmt.Fprintf(w, "%s\t%d\t%.0f%%\t\n", shortName(memberName(member.Member)), member.Completed, member.Share*100)
+		}
+		w.Flush()
+		b.WriteString("\n<b>Кто сколько сделал</b>\n<pre>" + html.EscapeString(table.String()) + "</pre>")
+	}
+	return b.String()
+}
+
+func (dh deliveryHandler) handleStats(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	stats, err := dh.taskUsecase.GetStats(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get stats")
+		return c.Send(internalError)
+	}
+	return c.Send(formatStats(stats), tele.ModeHTML)
+}
@@ -9,25 +9,38 @@ import (
 	"house-timer/internal/pkg/usecases/tasks"
 	"log"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
 
 	"github.com/go-logr/logr"
 	tele "gopkg.in/telebot.v3"
 )
 
 type deliveryHandler struct {
-	mainMenu           *tele.ReplyMarkup
-	taskEditMenu       *tele.ReplyMarkup
-	taskEditMenuGoBack *tele.ReplyMarkup
-	taskCreateStopMenu *tele.ReplyMarkup
-	logger             logr.Logger
-
-	taskUsecase entities.TaskUsecase
+	mainMenu              *tele.ReplyMarkup
+	taskEditMenu          *tele.ReplyMarkup
+	taskEditMenuGoBack    *tele.ReplyMarkup
+	taskCreateStopMenu    *tele.ReplyMarkup
+	regularityConfirmMenu *tele.ReplyMarkup
+	taskKindMenu          *tele.ReplyMarkup
+	notesMenu             *tele.ReplyMarkup
+	importCancelMenu      *tele.ReplyMarkup
+	importConfirmMenu     *tele.ReplyMarkup
+	deleteConfirmMenu     *tele.ReplyMarkup
+	btnUndoDelete         tele.Btn
+	btnTaskCategory       tele.Btn
+	btnRestoreTask        tele.Btn
+	logger                logr.Logger
+
+	taskUsecase     entities.TaskUsecase
+	calendarUsecase entities.CalendarUsecase
 }
 
-func NewDeliveryHandler(bot *tele.Bot, taskUsecase entities.TaskUsecase) {
+func NewDeliveryHandler(bot *tele.Bot, taskUsecase entities.TaskUsecase, calendarUsecase entities.CalendarUsecase) {
 	mainMenu := &tele.ReplyMarkup{}
 	btnNewTask := mainMenu.Data("Создад", "createTask")
 	btnEditTask := mainMenu.Data("Изменит", "editTask")
@@ -39,12 +52,32 @@ func NewDeliveryHandler(bot *tele.Bot, taskUsecase entities.TaskUsecase) {
 	taskEditMenu := &tele.ReplyMarkup{}
 	btnEditName := taskEditMenu.Data("Изменить название", "editTaskName")
 	btnEditRegularity := taskEditMenu.Data("Изменить регулярность", "editTaskRegularity")
+	btnMarkDone := taskEditMenu.Data("Отметить выполненным", "editMarkDone")
+	btnTogglePause := taskEditMenu.Data("Пауза / продолжить", "editTogglePause")
+	btnToggleAnchor := taskEditMenu.Data("Режим расписания", "editToggleAnchor")
+	btnEditDueDate := taskEditMenu.Data("Срок", "editDueDate")
+	btnEditNotes := taskEditMenu.Data("Заметки и вложения", "editNotes")
+	btnEditCategory := taskEditMenu.Data("Категория и теги", "editCategory")
+	btnEditChecklist := taskEditMenu.Data("Чеклист", "editChecklist")
+	btnEditDependency := taskEditMenu.Data("Идет после…", "editDependency")
+	btnEditSeason := taskEditMenu.Data("Сезон", "editSeason")
+	btnEditPoints := taskEditMenu.Data("Баллы", "editPoints")
+	btnToggleProof := taskEditMenu.Data("Фото-подтверждение", "editToggleProof")
+	btnShowProofs := taskEditMenu.Data("Фото выполнения", "editShowProofs")
 	btnDeleteTask := taskEditMenu.Data("Удалить задачу", "editDeleteTask")
 	btnEditGoBack := taskEditMenu.Data("Изменить другую задачу", "taskEditAnother")
 	btnEditStop := taskEditMenu.Data("Закончить изменение задач", "taskEditStop")
 	taskEditMenu.Inline(
 		taskEditMenu.Row(btnEditName),
 		taskEditMenu.Row(btnEditRegularity),
+		taskEditMenu.Row(btnMarkDone),
+		taskEditMenu.Row(btnTogglePause),
+		taskEditMenu.Row(btnToggleAnchor),
+		taskEditMenu.Row(btnEditDueDate, btnEditSeason),
+		taskEditMenu.Row(btnEditCategory, btnEditDependency),
+		taskEditMenu.Row(btnEditNotes, btnEditChecklist),
+		taskEditMenu.Row(btnToggleProof, btnShowProofs),
+		taskEditMenu.Row(btnEditPoints),
 		taskEditMenu.Row(btnDeleteTask),
 		taskEditMenu.Row(btnEditGoBack),
 		taskEditMenu.Row(btnEditStop),
@@ -62,31 +95,134 @@ func NewDeliveryHandler(bot *tele.Bot, taskUsecase entities.TaskUsecase) {
 		taskCreateStopMenu.Row(btnCreateStop),
 	)
 
-	dh := deliveryHandler{
-		mainMenu:           mainMenu,
-		taskEditMenu:       taskEditMenu,
-		taskEditMenuGoBack: taskEditMenuGoBack,
-		taskCreateStopMenu: taskCreateStopMenu,
-		logger:             logr.FromSlogHandler(slog.NewTextHandler(log.Writer(), nil)),
+	regularityConfirmMenu := &tele.ReplyMarkup{}
+	btnRegularityYes := regularityConfirmMenu.Data("Да", "regularityConfirm")
+	btnRegularityNo := regularityConfirmMenu.Data("Нет", "regularityReject")
+	regularityConfirmMenu.Inline(
+		regularityConfirmMenu.Row(btnRegularityYes, btnRegularityNo),
+		regularityConfirmMenu.Row(btnCreateStop),
+	)
+
+	taskKindMenu := &tele.ReplyMarkup{}
+	btnKindRepeat := taskKindMenu.Data("Повторять", "taskKindRepeat")
+	btnKindOnce := taskKindMenu.Data("Один раз", "taskKindOnce")
+	btnKindCounter := taskKindMenu.Data("По счетчику (км, литры)", "taskKindCounter")
+	btnKindCategory := taskKindMenu.Data("Категория и теги", "taskKindCategory")
+	taskKindMenu.Inline(
+		taskKindMenu.Row(btnKindRepeat, btnKindOnce),
+		taskKindMenu.Row(btnKindCounter),
+		taskKindMenu.Row(btnKindCategory),
+		taskKindMenu.Row(btnCreateStop),
+	)
+
+	notesMenu := &tele.ReplyMarkup{}
+	btnNotesDone := notesMenu.Data("Готово", "notesDone")
+	notesMenu.Inline(
+		notesMenu.Row(btnNotesDone),
+	)
+
+	importCancelMenu := &tele.ReplyMarkup{}
+	btnImportCancel := importCancelMenu.Data("Отмена", "importCancel")
+	importCancelMenu.Inline(
+		importCancelMenu.Row(btnImportCancel),
+	)
+
+	importConfirmMenu := &tele.ReplyMarkup{}
+	btnImportConfirm := importConfirmMenu.Data("Применить", "importConfirm")
+	importConfirmMenu.Inline(
+		importConfirmMenu.Row(btnImportConfirm),
+		importConfirmMenu.Row(btnImportCancel),
+	)
+
+	deleteConfirmMenu := &tele.ReplyMarkup{}
+	btnDeleteConfirm := deleteConfirmMenu.Data("Да, удалить", "deleteConfirm")
+	btnDeleteCancel := deleteConfirmMenu.Data("Нет", "deleteCancel")
+	deleteConfirmMenu.Inline(
+		deleteConfirmMenu.Row(btnDeleteConfirm, btnDeleteCancel),
+	)
 
-		taskUsecase: taskUsecase,
+	// buttons with task id in data are made per message
+	btnUndoDelete := tele.Btn{Unique: "undoDelete"}
+	btnRestoreTask := tele.Btn{Unique: "restoreTask"}
+	btnTaskCategory := tele.Btn{Unique: "taskCategory"}
+
+	dh := deliveryHandler{
+		mainMenu:              mainMenu,
+		taskEditMenu:          taskEditMenu,
+		taskEditMenuGoBack:    taskEditMenuGoBack,
+		taskCreateStopMenu:    taskCreateStopMenu,
+		regularityConfirmMenu: regularityConfirmMenu,
+		taskKindMenu:          taskKindMenu,
+		notesMenu:             notesMenu,
+		importCancelMenu:      importCancelMenu,
+		importConfirmMenu:     importConfirmMenu,
+		deleteConfirmMenu:     deleteConfirmMenu,
+		btnUndoDelete:         btnUndoDelete,
+		btnRestoreTask:        btnRestoreTask,
+		btnTaskCategory:       btnTaskCategory,
+		logger:                logr.FromSlogHandler(slog.NewTextHandler(log.Writer(), nil)),
+
+		taskUsecase:     taskUsecase,
+		calendarUsecase: calendarUsecase,
 	}
 
 	bot.Use(logmw.NewLogMW(dh.logger))
 	bot.Handle("/start", dh.handleStart)
+	bot.Handle("/ical", dh.handleICal)
+	bot.Handle("/export", dh.handleExport)
+	bot.Handle("/import", dh.handleImport)
+	bot.Handle("/trash", dh.handleTrash)
+	bot.Handle("/vacation", dh.handleVacation)
+	bot.Handle("/digest", dh.handleDigest)
+	bot.Handle("/quiet", dh.handleQuiet)
+	bot.Handle("/stats", dh.handleStats)
+	bot.Handle("/chart", dh.handleChart)
+	bot.Handle("/leaderboard", dh.handleLeaderboard)
+	bot.Handle("/done", dh.handleDone)
+	bot.Handle("/list", dh.handleList)
+	bot.Handle("/log", dh.handleLog)
 	bot.Handle(&btnNewTask, dh.handleNewTask)
 	bot.Handle(&btnEditTask, dh.handleEditTask)
 
 	bot.Handle(&btnEditName, dh.handleEditTaskName)
 	bot.Handle(&btnEditRegularity, dh.handleEditTaskRegularity)
+	bot.Handle(&btnMarkDone, dh.handleMarkDone)
+	bot.Handle(&btnTogglePause, dh.handleTogglePause)
+	bot.Handle(&btnToggleAnchor, dh.handleToggleAnchor)
+	bot.Handle(&btnEditDueDate, dh.handleEditDueDate)
+	bot.Handle(&btnEditNotes, dh.handleEditNotes)
+	bot.Handle(&btnEditCategory, dh.handleEditCategory)
+	bot.Handle(&btnEditChecklist, dh.handleEditChecklist)
+	bot.Handle(&btnEditDependency, dh.handleEditDependency)
+	bot.Handle(&btnEditSeason, dh.handleEditSeason)
+	bot.Handle(&btnEditPoints, dh.handleEditPoints)
+	bot.Handle(&btnTaskCategory, dh.handleChooseCategory)
+	bot.Handle(&btnNotesDone, dh.handleNotesDone)
+	bot.Handle(&btnToggleProof, dh.handleToggleProof)
+	bot.Handle(&btnShowProofs, dh.handleShowProofs)
 	bot.Handle(&btnDeleteTask, dh.handleDeleteTask)
+	bot.Handle(&btnDeleteConfirm, dh.handleDeleteConfirm)
+	bot.Handle(&btnDeleteCancel, dh.handleDeleteCancel)
+	bot.Handle(&btnUndoDelete, dh.handleUndoDelete)
+	bot.Handle(&btnRestoreTask, dh.handleRestoreTask)
 
 	bot.Handle(&btnEditGoBack, dh.handleEditGoBack)
 	bot.Handle(&btnEditStop, dh.handleEditStop)
 
 	bot.Handle(&btnCreateStop, dh.handleCreateStop)
+	bot.Handle(&btnRegularityYes, dh.handleRegularityConfirm)
+	bot.Handle(&btnRegularityNo, dh.handleRegularityReject)
+	bot.Handle(&btnKindRepeat, dh.handleKindRepeat)
+	bot.Handle(&btnKindOnce, dh.handleKindOnce)
+	bot.Handle(&btnKindCounter, dh.handleKindCounter)
+	bot.Handle(&btnKindCategory, dh.handleKindCategory)
+
+	bot.Handle(&btnImportConfirm, dh.handleImportConfirm)
+	bot.Handle(&btnImportCancel, dh.handleImportCancel)
 
 	bot.Handle(tele.OnText, dh.handleMessages)
+	bot.Handle(tele.OnDocument, dh.handleDocument)
+	bot.Handle(tele.OnPhoto, dh.handlePhoto)
 }
 
 const internalError = "Что-то пошло не так, обратитесь к @paulnopaul"
@@ -157,6 +293,8 @@ func (dh deliveryHandler) handleMessages(c tele.Context) error {
 	switch eventType {
 	case entities.TaskCreationEvent:
 		return dh.handleCreationMessage(c, chatID)
+	case entities.TaskImportEvent:
+		return c.Send("Жду файл для загрузки, пришлите его документом", dh.importCancelMenu)
 	}
 	return dh.handleEditMessage(c, chatID)
 }
@@ -170,44 +308,228 @@ func (dh deliveryHandler) handleCreationMessage(c tele.Context, chatID int64) er
 		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
 			return c.Send(unknownAction, dh.mainMenu)
 		} else if errors.Is(err, tasks.ErrParseRegularity) {
-			return c.Send("Неверный формат регулярности напоминания, попробуйте еще раз")
+			return c.Send(regularityErrorMessage(err))
+		} else if errors.Is(err, tasks.ErrParseDate) {
+			return c.Send(dateError)
+		} else if errors.Is(err, tasks.ErrParseAmount) {
+			return c.Send("Не понял число. " + thresholdQuestion)
 		}
 		log.Error(err, "failed to handle task message")
 		return c.Send(internalError)
 	}
 	if res.IsTaskNameCreated() {
-		return c.Send("Отлично! Как часто о ней надо напоминать?\n Ответь в формате N дней/недель/месяцев", dh.taskCreateStopMenu)
+		return c.Send("Отлично! "+taskKindQuestion+" Категорию и теги можно добавить кнопкой ниже", dh.taskKindMenu)
 	}
-	if res.IsTaskCreated() {
-		chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if res.IsNeedTaskKind() {
+		return c.Send(taskKindQuestion, dh.taskKindMenu)
+	}
+	if res.IsNeedRegularity() {
+		task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
 		if err != nil {
-			log.Error(err, "failed to get tasks")
+			log.Error(err, "failed to get current task")
 			return c.Send(internalError)
 		}
-		return c.Send("Прекрасно! Задачка создана, вы изумительны\n"+formatTasks(chatTasks), dh.mainMenu)
+		if task.CounterTask() {
+			return c.Send(fallbackQuestion, dh.taskCreateStopMenu)
+		}
+		return c.Send(regularityQuestion, dh.taskCreateStopMenu)
+	}
+	if res.IsNeedThreshold() {
+		return c.Send(thresholdQuestion, dh.taskCreateStopMenu)
+	}
+	if res.IsNeedDueDate() {
+		return c.Send(dueDateQuestion, dh.taskCreateStopMenu)
+	}
+	if res.IsNeedDueDateConfirm() {
+		task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get current task")
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf(dueDateGuess, task.DueAt.Format("02.01.2006")), dh.taskCreateStopMenu)
+	}
+	if res.IsNeedRegularityConfirm() || res.IsRegularityParsed() {
+		task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get current task")
+			return c.Send(internalError)
+		}
+		question := "Напоминать %s?"
+		if res.IsNeedRegularityConfirm() {
+			question = "Вы имели в виду %s?"
+		}
+		return c.Send(fmt.Sprintf(question, regularity.FormatEvery(task.Regularity)), dh.regularityConfirmMenu)
+	}
+	if res.IsTaskCreated() {
+		return dh.sendTaskCreated(c, ctx, chatID)
 	}
 	return c.Send("Я заблудился, напишите администратору @paulnopaul")
 }
 
+func (dh deliveryHandler) sendTaskCreated(c tele.Context, ctx context.Context, chatID int64) error {
+	log := logmw.GetLogger(c)
+	chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get tasks")
+		return c.Send(internalError)
+	}
+	return c.Send("Прекрасно! Задачка создана, вы изумительны\n"+formatTasks(chatTasks), dh.mainMenu)
+}
+
+func (dh deliveryHandler) handleRegularityConfirm(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to confirm regularity")
+		return c.Send(internalError)
+	}
+	return dh.sendTaskCreated(c, ctx, chatID)
+}
+
+func (dh deliveryHandler) handleRegularityReject(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.RejectTaskRegularity(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время создания задачи", dh.mainMenu)
+		}
+		log.Error(err, "failed to reject regularity")
+		return c.Send(internalError)
+	}
+	return c.Send("Тогда напишите еще раз, как часто напоминать", dh.taskCreateStopMenu)
+}
+
 func (dh deliveryHandler) handleEditMessage(c tele.Context, chatID int64) error {
 	res, err := dh.taskUsecase.HandleTaskMessage(context.Background(), chatID, c.Message().Text)
 	if err != nil {
 		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
 			return c.Send(unknownAction, dh.mainMenu)
 		} else if errors.Is(err, tasks.ErrParseRegularity) {
-			return c.Send("Неверный формат регулярности напоминания, попробуйте еще раз")
+			return c.Send(regularityErrorMessage(err))
 		} else if errors.Is(err, tasks.ErrBadTaskNumber) {
 			return c.Send("Некорректный номер задачи, попробуйте еще раз")
+		} else if errors.Is(err, tasks.ErrParseDate) {
+			return c.Send(dateError)
+		} else if errors.Is(err, tasks.ErrEmptyChecklist) {
+			return c.Send(checklistQuestion)
+		} else if errors.Is(err, tasks.ErrUnknownTask) {
+			return c.Send("Не нашел такую задачу, напишите ее номер или название")
+		} else if errors.Is(err, tasks.ErrDependencyCycle) {
+			return c.Send("Так получится замкнутый круг: эта задача сама идет раньше той, выберите другую")
+		} else if errors.Is(err, tasks.ErrParseSeason) {
+			return c.Send("Не понял даты сезона. " + seasonQuestion)
+		} else if errors.Is(err, tasks.ErrParsePoints) {
+			return c.Send("Не понял, сколько баллов. " + pointsQuestion)
 		}
 		log.Println(err)
 		return c.Send(internalError)
 	}
 	if res.IsGotEditNumberTaskResult() {
-		return c.Send("Выберите действие", dh.taskEditMenu)
+		return dh.sendEditMenu(c, chatID, "")
+	} else if res.IsNeedRegularityConfirm() || res.IsNeedDueDateConfirm() || res.IsNeedDelayConfirm() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		if res.IsNeedDueDateConfirm() {
+			return c.Send(fmt.Sprintf(dueDateGuess, task.DueAt.Format("02.01.2006")), dh.taskEditMenuGoBack)
+		} else if res.IsNeedDelayConfirm() {
+			return c.Send(fmt.Sprintf("Вы имели в виду %s? Напишите «да» или исправьте", formatDelay(task.AfterDelay)), dh.taskEditMenuGoBack)
+		}
+		return c.Send(fmt.Sprintf("Вы имели в виду %s? Напишите «да» или как часто напоминать",
+			regularity.FormatEvery(task.Regularity)), dh.taskEditMenuGoBack)
 	} else if res.IsGotEditNameTaskResult() {
 		return c.Send("Название изменено, выберите действие", dh.taskEditMenu)
 	} else if res.IsGotEditRegularityTaskResult() {
-		return c.Send("Регулярность изменена, выберите действие", dh.taskEditMenu)
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf("Теперь напоминаю %s, выберите действие", regularity.FormatEvery(task.Regularity)), dh.taskEditMenu)
+	} else if res.IsGotEditDoneTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf("Отмечено, следующее напоминание %s, выберите действие", task.NextRemind().Format("02.01")), dh.taskEditMenu)
+	} else if res.IsGotEditAnchorTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf("Напоминаю %s начиная с %s, следующее напоминание %s, выберите действие",
+			regularity.FormatEvery(task.Regularity), task.StartAt.Format("02.01"), task.NextRemind().Format("02.01")), dh.taskEditMenu)
+	} else if res.IsGotEditDueDateTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		if task.DueAt.IsZero() {
+			return c.Send("Срок убран, выберите действие", dh.taskEditMenu)
+		}
+		return c.Send(fmt.Sprintf("Срок: %s, выберите действие", task.DueAt.Format("02.01.2006")), dh.taskEditMenu)
+	} else if res.IsGotEditSeasonTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		if len(task.Season) == 0 {
+			return c.Send("Напоминаю круглый год, выберите действие", dh.taskEditMenu)
+		}
+		return c.Send(fmt.Sprintf("Напоминаю только %s, следующее напоминание %s, выберите действие",
+			formatSeason(task.Season), task.NextRemind().Format("02.01")), dh.taskEditMenu)
+	} else if res.IsGotEditPointsTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		return c.Send(fmt.Sprintf("За задачу %s баллов, выберите действие", formatPoints(task)), dh.taskEditMenu)
+	} else if res.IsGotEditDependencyTaskResult() {
+		return dh.sendEditMenu(c, chatID, "Зависимость сохранена. ")
+	} else if res.IsGotEditChecklistTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		items, err := dh.taskUsecase.GetTaskChecklist(context.Background(), task.ID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		if len(items) == 0 {
+			return c.Send("Чеклист удален, выберите действие", dh.taskEditMenu)
+		}
+		return c.Send("Чеклист сохранен:\n"+formatChecklist(items)+"Выберите действие", dh.taskEditMenu)
+	} else if res.IsGotEditCategoryTaskResult() {
+		task, err := dh.taskUsecase.CurrentTask(context.Background(), chatID)
+		if err != nil {
+			log.Println(err)
+			return c.Send(internalError)
+		}
+		return c.Send(formatLabels(task)+", выберите действие", dh.taskEditMenu)
+	} else if res.IsGotEditNotesTaskResult() {
+		return c.Send("Заметка сохранена, можно добавить фото или файлы", dh.notesMenu)
+	} else if res.IsClearedNotesTaskResult() {
+		return c.Send("Заметка и вложения удалены", dh.notesMenu)
 	}
 	return c.Send("Я заблудился, напишите администратору @paulnopaul")
 }
@@ -308,13 +630,35 @@ func (dh deliveryHandler) handleDeleteTask(c tele.Context) error {
 	chatID := c.Chat().ID
 	log := logmw.GetLogger(c)
 	ctx := logr.NewContext(context.Background(), log)
-	err := dh.taskUsecase.DeleteCurrentTask(ctx, chatID)
+	err := dh.taskUsecase.StartTaskDelete(ctx, chatID)
 	if err != nil {
 		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
 			return c.Send(unknownAction, dh.mainMenu)
 		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
 			return c.Send("Вы не можете это жмакнуть, не начав редактировать задачу", dh.mainMenu)
 		}
+		log.Error(err, "failed to start task delete")
+		return c.Send(internalError)
+	}
+	task, err := dh.taskUsecase.CurrentTask(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get current task")
+		return c.Send(internalError)
+	}
+	return c.Send(fmt.Sprintf("Точно удалить «%s»?", task.Name), dh.deleteConfirmMenu)
+}
+
+func (dh deliveryHandler) handleDeleteConfirm(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	taskID, err := dh.taskUsecase.DeleteCurrentTask(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав удалять задачу", dh.mainMenu)
+		}
 		log.Error(err, "failed to delete task")
 		return c.Send(internalError)
 	}
@@ -323,21 +667,104 @@ func (dh deliveryHandler) handleDeleteTask(c tele.Context) error {
 		log.Error(err, "cant get tasks")
 		return c.Send(internalError)
 	}
+	// undo button goes on top of the main menu
+	menu := &tele.ReplyMarkup{}
+	btnUndo := menu.Data("Отменить", dh.btnUndoDelete.Unique, strconv.FormatInt(taskID, 10))
+	menu.InlineKeyboard = append([][]tele.InlineButton{{*btnUndo.Inline()}}, dh.mainMenu.InlineKeyboard...)
+	text := fmt.Sprintf("Задача удалена, ее можно вернуть в течение %d минут или через /trash\n",
+		int(tasks.UndoDeleteWindow.Minutes()))
 	if len(chatTasks) == 0 {
-		return c.Send("У вас нет задач", dh.mainMenu)
+		return c.Send(text+"У вас нет задач", menu)
+	}
+	return c.Send(text+formatTasks(chatTasks), menu)
+}
+
+func (dh deliveryHandler) handleDeleteCancel(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+	err := dh.taskUsecase.CancelTaskDelete(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+			return c.Send(unknownAction, dh.mainMenu)
+		} else if errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Вы не можете это жмакнуть, не начав удалять задачу", dh.mainMenu)
+		}
+		log.Error(err, "failed to cancel task delete")
+		return c.Send(internalError)
+	}
+	return c.Send("Не удаляем, выберите действие", dh.taskEditMenu)
+}
+
+func regularityErrorMessage(err error) string {
+	var unitErr *regularity.UnknownUnitError
+	if errors.As(err, &unitErr) {
+		return fmt.Sprintf("Не понимаю, что такое «%s». Может быть, %s? Попробуйте еще раз",
+			unitErr.Word, strings.Join(unitErr.Suggestions, ", "))
 	}
-	return c.Send("Задача успешно удалена, ваши задачи:\n"+formatTasks(chatTasks), dh.mainMenu)
+	if errors.Is(err, regularity.ErrTooShort) {
+		return "Напоминаю не чаще раза в день, укажите регулярность от одного дня"
+	}
+	return "Неверный формат регулярности напоминания, попробуйте еще раз"
 }
 
+// formatTasks lists tasks grouped by category, numbers stay the same as in the flat list
 func formatTasks(tasks []entities.UserTask) string {
 	res := "Ваши задачи:\n"
+	var categories []string
+	byCategory := map[string][]int{}
 	for i, task := range tasks {
+		if _, ok := byCategory[task.Category]; !ok && task.Category != "" {
+			categories = append(categories, task.Category)
+		}
+		byCategory[task.Category] = append(byCategory[task.Category], i)
+	}
+	if len(categories) == 0 {
+		return res + formatTaskLines(tasks, byCategory[""])
+	}
+	for _, category := range categories {
+		res += "\n" + category + ":\n" + formatTaskLines(tasks, byCategory[category])
+	}
+	if len(byCategory[""]) > 0 {
+		res += "\nБез категории:\n" + formatTaskLines(tasks, byCategory[""])
+	}
+	return res
+}
+
+// formatTaskLines formats tasks with the given indexes
+func formatTaskLines(tasks []entities.UserTask, indexes []int) string {
+	res := ""
+	for _, i := range indexes {
+		task := tasks[i]
+		name := task.Name
+		for _, tag := range task.Tags {
+			name += " #" + tag
+		}
+		schedule := regularity.FormatEvery(task.Regularity)
+		if task.OneOff {
+			schedule = "один раз " + task.DueAt.Format("02.01.2006")
+		} else if task.Anchor == entities.AnchorFixed {
+			schedule += " по расписанию"
+		}
+		if task.Paused() {
+			res += fmt.Sprintf("%d. %s %s, на паузе с %s\n", i+1, name, schedule, task.PausedAt.Format("02.01"))
+			continue
+		}
+		if task.CounterTask() {
+			schedule = fmt.Sprintf("по счетчику %s/%s, но не реже чем %s",
+				formatAmount(task.Counter), formatAmount(task.Threshold)+unitSuffix(task.CounterUnit), schedule)
+		}
+		deadline := ""
+		if !task.OneOff && !task.DueAt.IsZero() {
+			deadline = ", срок до " + task.DueAt.Format("02.01")
+		}
+		deadline += formatSeasonStatus(task, time.Now())
 		// TODO: сделать красиво
-		res += fmt.Sprintf("%d. %s каждые %d дней, до напоминания: %d дней\n", i+1, task.Name, int64(task.Regularity.Hours()/24), getRemindEst(task))
+		res += fmt.Sprintf("%d. %s %s, до напоминания: %d дней%s\n", i+1, name, schedule, getRemindEst(task), deadline)
 	}
 	return res
 }
 
 func getRemindEst(task entities.UserTask) int64 {
-	return int64(time.Until(task.LastReminded.Truncate(24*time.Hour).Add(task.Regularity).Add(task.RemindAfter)) / (24 * time.Hour))
+	return int64(time.Until(task.NextDue().Add(task.RemindAfter)) / (24 * time.Hour))
 }
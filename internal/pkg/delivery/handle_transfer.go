@@ -0,0 +1,206 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/transfer"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+// importRef is stored while import waits for confirmation
+type importRef struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name"`
+}
+
+func (dh deliveryHandler) handleExport(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	export, err := dh.taskUsecase.ExportChat(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to export chat")
+		return c.Send(internalError)
+	}
+	jsonData, err := transfer.EncodeJSON(export)
+	if err != nil {
+		log.Error(err, "failed to encode json export")
+		return c.Send(internalError)
+	}
+	csvData, err := transfer.EncodeCSV(export)
+	if err != nil {
+		log.Error(err, "failed to encode csv export")
+		return c.Send(internalError)
+	}
+	name := "house-timer-" + export.ExportedAt.Format(time.DateOnly)
+	err = c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(jsonData)),
+		FileName: name + ".json",
+		MIME:     "application/json",
+		Caption:  "Задачи, настройки и история. Этот файл можно загрузить обратно через /import",
+	})
+	if err != nil {
+		return err
+	}
+	return c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(csvData)),
+		FileName: name + ".csv",
+		MIME:     "text/csv",
+		Caption:  "Только задачи, для таблиц",
+	})
+}
+
+func (dh deliveryHandler) handleImport(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	err := dh.taskUsecase.StartImport(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrEventCollision) {
+			return c.Send("Надо закончить предыдущее действие, чтобы загрузить задачи")
+		}
+		log.Error(err, "failed to start import")
+		return c.Send(internalError)
+	}
+	return c.Send("Пришлите файл из /export (.json или .csv)", dh.importCancelMenu)
+}
+
+func (dh deliveryHandler) readExport(c tele.Context, ref importRef) (entities.ChatExport, error) {
+	file, err := c.Bot().File(&tele.File{FileID: ref.FileID})
+	if err != nil {
+		return entities.ChatExport{}, err
+	}
+	defer file.Close()
+	return transfer.Decode(ref.FileName, file)
+}
+
+func formatImportDiff(diff entities.ImportDiff) string {
+	res := ""
+	if len(diff.Created) > 0 {
+		res += "Новые задачи: " + strings.Join(diff.Created, ", ") + "\n"
+	}
+	if len(diff.Updated) > 0 {
+		res += "Изменятся: " + strings.Join(diff.Updated, ", ") + "\n"
+	}
+	if len(diff.Unchanged) > 0 {
+		res += "Без изменений: " + strings.Join(diff.Unchanged, ", ") + "\n"
+	}
+	if diff.NewHistory > 0 {
+		res += fmt.Sprintf("Записей в истории: %d\n", diff.NewHistory)
+	}
+	if res == "" {
+		return "Файл пустой, менять нечего\n"
+	}
+	return res
+}
+
+func (dh deliveryHandler) handleDocument(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	eventType, err := dh.taskUsecase.CurrentEventType(ctx, chatID)
+	if err != nil && !errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+		log.Error(err, "failed to get current event type")
+		return c.Send(internalError)
+	}
+	if eventType == entities.TaskEditEvent {
+		return dh.addAttachment(c, c.Message().Document.FileID, entities.AttachmentDocument)
+	}
+	if eventType != entities.TaskImportEvent {
+		return c.Send("Чтобы загрузить задачи из файла, сначала нажмите /import", dh.mainMenu)
+	}
+
+	doc := c.Message().Document
+	ref := importRef{FileID: doc.FileID, FileName: doc.FileName}
+	export, err := dh.readExport(c, ref)
+	if err != nil {
+		if errors.Is(err, transfer.ErrBadFormat) {
+			return c.Send("Не получилось прочитать файл, это точно файл из /export?", dh.importCancelMenu)
+		}
+		log.Error(err, "failed to read export")
+		return c.Send(internalError)
+	}
+	payload, err := json.Marshal(ref)
+	if err != nil {
+		log.Error(err, "failed to marshal import ref")
+		return c.Send(internalError)
+	}
+	diff, err := dh.taskUsecase.PreviewImport(ctx, chatID, string(payload), export)
+	if err != nil {
+		if errors.Is(err, tasks.ErrInvalidImport) {
+			log.Info("invalid import", "err", err.Error())
+			return c.Send("В файле ошибка, проверьте названия и регулярности задач", dh.importCancelMenu)
+		}
+		log.Error(err, "failed to preview import")
+		return c.Send(internalError)
+	}
+	return c.Send("Вот что изменится:\n"+formatImportDiff(diff)+"Применяем?", dh.importConfirmMenu)
+}
+
+func (dh deliveryHandler) handleImportConfirm(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	payload, err := dh.taskUsecase.ImportFileRef(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) || errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Сначала пришлите файл для загрузки", dh.mainMenu)
+		}
+		log.Error(err, "failed to get import file")
+		return c.Send(internalError)
+	}
+	var ref importRef
+	if err := json.Unmarshal([]byte(payload), &ref); err != nil {
+		log.Error(err, "failed to unmarshal import ref")
+		return c.Send(internalError)
+	}
+	export, err := dh.readExport(c, ref)
+	if err != nil {
+		log.Error(err, "failed to read export")
+		return c.Send(internalError)
+	}
+	diff, err := dh.taskUsecase.ApplyImport(ctx, chatID, export)
+	if err != nil {
+		log.Error(err, "failed to apply import")
+		return c.Send(internalError)
+	}
+	log.Info("import applied", "created", len(diff.Created), "updated", len(diff.Updated), "history", diff.NewHistory)
+	chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get tasks")
+		return c.Send(internalError)
+	}
+	return c.Send("Готово!\n"+formatTasks(chatTasks), dh.mainMenu)
+}
+
+func (dh deliveryHandler) handleImportCancel(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	err := dh.taskUsecase.StopImport(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) || errors.Is(err, tasks.ErrBadTaskEvent) {
+			return c.Send("Эту кнопку можно нажать только во время загрузки задач", dh.mainMenu)
+		}
+		log.Error(err, "failed to stop import")
+		return c.Send(internalError)
+	}
+	return c.Send("Что делать будем?", dh.mainMenu)
+}
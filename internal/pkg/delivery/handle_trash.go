@@ -0,0 +1,92 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"house-timer/internal/pkg/logmw"
+	"house-timer/internal/pkg/usecases/tasks"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+func (dh deliveryHandler) handleTrash(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	deleted, err := dh.taskUsecase.GetDeletedTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get deleted tasks")
+		return c.Send(internalError)
+	}
+	if len(deleted) == 0 {
+		return c.Send("Корзина пуста", dh.mainMenu)
+	}
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	res := "Удаленные задачи:\n"
+	for i, task := range deleted {
+		res += fmt.Sprintf("%d. %s, удалена %s\n", i+1, task.Name, task.DeletedAt.Format("02.01.2006"))
+		rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("Вернуть «%s»", task.Name), dh.btnRestoreTask.Unique, strconv.FormatInt(task.ID, 10))))
+	}
+	menu.Inline(rows...)
+	return c.Send(res, menu)
+}
+
+func (dh deliveryHandler) sendRestored(c tele.Context, ctx context.Context, chatID int64) error {
+	log := logmw.GetLogger(c)
+	chatTasks, err := dh.taskUsecase.GetTasks(ctx, chatID)
+	if err != nil {
+		log.Error(err, "failed to get tasks")
+		return c.Send(internalError)
+	}
+	return c.Send("Задача восстановлена\n"+formatTasks(chatTasks), dh.mainMenu)
+}
+
+func (dh deliveryHandler) handleUndoDelete(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	taskID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		log.Error(err, "bad undo delete data", "data", c.Data())
+		return c.Send(internalError)
+	}
+	err = dh.taskUsecase.UndoDeleteTask(ctx, chatID, taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrUndoExpired) {
+			return c.Send("Отменить уже нельзя, но задачу можно вернуть через /trash")
+		} else if errors.Is(err, tasks.ErrNotInTrash) {
+			return c.Send("Задача уже восстановлена", dh.mainMenu)
+		}
+		log.Error(err, "failed to undo delete")
+		return c.Send(internalError)
+	}
+	return dh.sendRestored(c, ctx, chatID)
+}
+
+func (dh deliveryHandler) handleRestoreTask(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	taskID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		log.Error(err, "bad restore data", "data", c.Data())
+		return c.Send(internalError)
+	}
+	err = dh.taskUsecase.RestoreTask(ctx, chatID, taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrNotInTrash) {
+			return c.Send("Этой задачи уже нет в корзине", dh.mainMenu)
+		}
+		log.Error(err, "failed to restore task")
+		return c.Send(internalError)
+	}
+	return dh.sendRestored(c, ctx, chatID)
+}
@@ -0,0 +1,17 @@
+package entities
+
+type AttachmentKind string
+
+const (
+	AttachmentPhoto    AttachmentKind = "photo"
+	AttachmentDocument AttachmentKind = "document"
+)
+
+// Attachment is a file sent to the bot, stored as telegram file id
+// which is only valid for the same bot
+type Attachment struct {
+	ID     int64
+	TaskID int64
+	FileID string
+	Kind   AttachmentKind
+}
@@ -0,0 +1,29 @@
+package entities
+
+import "strings"
+
+// DefaultCategories are offered during creation before the chat has its own
+var DefaultCategories = []string{"Кухня", "Ванная", "Комнаты", "Растения", "Машина"}
+
+// NormalizeLabel makes categories and tags comparable, "#Кухня" and "кухня" are the same
+func NormalizeLabel(label string) string {
+	label = strings.TrimPrefix(strings.TrimSpace(label), "#")
+	return strings.ReplaceAll(strings.ToLower(label), "ё", "е")
+}
+
+// HasLabel reports whether the task is in the category or has the tag
+func (t UserTask) HasLabel(label string) bool {
+	label = NormalizeLabel(label)
+	if label == "" {
+		return false
+	}
+	if NormalizeLabel(t.Category) == label {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if NormalizeLabel(tag) == label {
+			return true
+		}
+	}
+	return false
+}
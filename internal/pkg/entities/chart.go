@@ -0,0 +1,9 @@
+package entities
+
+// Chart is a rendered png of a task or of the whole chat when Task is zero,
+// Members are in the order of the chart palette
+type Chart struct {
+	Task    UserTask
+	Members []Member
+	Image   []byte
+}
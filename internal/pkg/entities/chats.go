@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// ICalTokenBytes is the length of the random calendar feed token, it is stored hex encoded
+const ICalTokenBytes = 16
+
+// Chat holds per chat settings
+type Chat struct {
+	ChatID    int64
+	ICalToken string
+	// VacationFrom and VacationUntil are zero when the chat is not on vacation
+	VacationFrom  time.Time
+	VacationUntil time.Time
+	Digest        DigestSettings
+	// DigestSentAt is zero when no digest was sent yet
+	DigestSentAt time.Time
+	Quiet        QuietHours
+}
+
+// OnVacation reports whether reminders of the chat are suspended at now
+func (c *Chat) OnVacation(now time.Time) bool {
+	return !c.VacationFrom.IsZero() && now.Before(c.VacationUntil)
+}
+
+// VacationOver reports whether the chat has a vacation that ended but was not closed yet
+func (c *Chat) VacationOver(now time.Time) bool {
+	return !c.VacationFrom.IsZero() && !now.Before(c.VacationUntil)
+}
+
+type ChatStorage interface {
+	// GetChat returns default settings for chats without saved ones
+	GetChat(ctx context.Context, chatID int64) (Chat, error)
+	GetChatByICalToken(ctx context.Context, token string) (Chat, error)
+	SetICalToken(ctx context.Context, chatID int64, token string) error
+	SetVacation(ctx context.Context, chatID int64, from time.Time, until time.Time) error
+	ClearVacation(ctx context.Context, chatID int64) error
+	SetDigest(ctx context.Context, chatID int64, settings DigestSettings) error
+	SetDigestSentAt(ctx context.Context, chatID int64, at time.Time) error
+	SetQuietHours(ctx context.Context, chatID int64, quiet QuietHours) error
+}
+
+type CalendarUsecase interface {
+	ExportCalendar(ctx context.Context, chatID int64) ([]byte, error)
+	// FeedURL returns empty string when the feed is disabled
+	FeedURL(ctx context.Context, chatID int64) (string, error)
+	ExportFeed(ctx context.Context, token string) ([]byte, error)
+}
@@ -0,0 +1,21 @@
+package entities
+
+// ChecklistItem is a step of the task, items are ticked during a reminder
+// and unticked when the occurrence is finished
+type ChecklistItem struct {
+	ID       int64
+	TaskID   int64
+	Position int
+	Text     string
+	Done     bool
+}
+
+// ChecklistDone reports whether every item is ticked, empty checklist is never done
+func ChecklistDone(items []ChecklistItem) bool {
+	for _, item := range items {
+		if !item.Done {
+			return false
+		}
+	}
+	return len(items) > 0
+}
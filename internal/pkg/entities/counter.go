@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// Reading is a usage logged for a counter task, e.g. 120 litres of filtered water
+type Reading struct {
+	ID       int64
+	TaskID   int64
+	Value    float64
+	LoggedAt time.Time
+}
+
+// CounterTask is due by usage, Regularity is the time fallback
+func (t UserTask) CounterTask() bool {
+	return t.Threshold > 0
+}
+
+func (t UserTask) CounterReached() bool {
+	return t.CounterTask() && t.Counter >= t.Threshold
+}
@@ -0,0 +1,69 @@
+package entities
+
+import "time"
+
+type DigestPeriod string
+
+const (
+	DigestOff    DigestPeriod = ""
+	DigestDaily  DigestPeriod = "daily"
+	DigestWeekly DigestPeriod = "weekly"
+)
+
+// Duration is the time the digest looks back and forward
+func (p DigestPeriod) Duration() time.Duration {
+	if p == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// DigestSettings tell when the digest is sent to the chat, the hour is in the chat time zone, see QuietHours.Offset
+type DigestSettings struct {
+	Period  DigestPeriod
+	Hour    int
+	Weekday time.Weekday
+	// Only replaces individual reminders with the digest
+	Only bool
+}
+
+// digestSlot returns the latest time the digest should have been sent at before now
+func (c *Chat) digestSlot(now time.Time) time.Time {
+	now = now.UTC().Add(c.Quiet.Offset)
+	slot := time.Date(now.Year(), now.Month(), now.Day(), c.Digest.Hour, 0, 0, 0, time.UTC)
+	if c.Digest.Period == DigestWeekly {
+		slot = slot.AddDate(0, 0, -int((7+now.Weekday()-c.Digest.Weekday)%7))
+	}
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -int(c.Digest.Period.Duration()/(24*time.Hour)))
+	}
+	return slot.Add(-c.Quiet.Offset)
+}
+
+// DigestDue reports whether the digest of the chat was not sent since its time came
+func (c *Chat) DigestDue(now time.Time) bool {
+	if c.Digest.Period == DigestOff {
+		return false
+	}
+	return c.DigestSentAt.Before(c.digestSlot(now))
+}
+
+type DigestDone struct {
+	Task  UserTask
+	Times int
+}
+
+// Digest lists tasks of the chat for the period around its time
+type Digest struct {
+	Period DigestPeriod
+	// Overdue tasks were due before today
+	Overdue []UserTask
+	// Upcoming tasks are due from today till the end of the period
+	Upcoming []UserTask
+	// Done are tasks completed during the last period
+	Done []DigestDone
+}
+
+func (d Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.Upcoming) == 0 && len(d.Done) == 0
+}
@@ -3,7 +3,10 @@ package entities
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"house-timer/pkg/regularity"
 )
 
 type DBEntity struct {
@@ -22,6 +25,16 @@ type Task struct {
 	LastUpdated time.Time
 }
 
+// AnchorMode tells what the schedule of a task is counted from
+type AnchorMode string
+
+const (
+	// AnchorCompletion counts the next reminder from the last completion
+	AnchorCompletion AnchorMode = "completion"
+	// AnchorFixed keeps reminders on StartAt + k * Regularity regardless of completions
+	AnchorFixed AnchorMode = "fixed"
+)
+
 type UserTask struct {
 	ID           int64
 	Name         string
@@ -29,21 +42,141 @@ type UserTask struct {
 	Regularity   time.Duration
 	LastReminded time.Time
 	RemindAfter  time.Duration
+	// DeletedAt is zero for tasks not in trash
+	DeletedAt time.Time
+	// PausedAt is zero for active tasks
+	PausedAt time.Time
+	Anchor   AnchorMode
+	// StartAt is the first occurrence of AnchorFixed tasks
+	StartAt time.Time
+	// OneOff tasks have no regularity, they are reminded once on DueAt and archived after completion
+	OneOff bool
+	// DueAt is the date of one-off tasks and an optional hard deadline of recurring ones
+	DueAt      time.Time
+	ArchivedAt time.Time
+	// Notes are instructions sent with reminders
+	Notes string
+	// RequiresProof tasks are completed from reminder only with a photo
+	RequiresProof bool
+	// Category groups tasks in the list, e.g. a room
+	Category string
+	Tags     []string
+	// AfterTaskID is the task whose completion makes this one due AfterDelay later
+	AfterTaskID int64
+	AfterDelay  time.Duration
+	// ChainedAt is when the completion of AfterTaskID makes the task due, zero until it is completed
+	ChainedAt time.Time
+	// Threshold of Counter makes the task due, zero for tasks due by time only
+	Threshold   float64
+	CounterUnit string
+	// Counter is the usage since the last completion
+	Counter float64
+	// CounterReachedAt is when the counter reached the threshold, zero while it is not reached
+	// or after the occurrence is skipped
+	CounterReachedAt time.Time
+	// Season limits the task to parts of the year, empty for all year round
+	Season []regularity.Window
+	// SuggestedRegularity is the regularity offered from the history, zero when there is no offer
+	SuggestedRegularity time.Duration
+	// SuggestedAt is when the offer was sent, completions before it are not analysed again
+	SuggestedAt time.Time
+	// Points are awarded for a completion, zero for the default ones
+	Points int
+}
+
+// NormalizeName makes task names comparable, tasks are found and imported tasks are matched by it
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (u *UserTask) Paused() bool {
+	return !u.PausedAt.IsZero()
 }
 
 func (u *UserTask) Recipient() string {
 	return fmt.Sprintf("%d", u.ChatID)
 }
 
+// RemindHour is the time of day (UTC) when due tasks are reminded
+const RemindHour = 15 * time.Hour
+
+// NextRemind returns the time when the task is going to be reminded
+func (u *UserTask) NextRemind() time.Time {
+	return u.NextDue().Add(u.RemindAfter).Add(RemindHour)
+}
+
+// SuggestionPending reports whether the offered regularity is not sent yet
+func (u *UserTask) SuggestionPending() bool {
+	return u.SuggestedRegularity > 0 && u.SuggestedAt.IsZero()
+}
+
+// NextDue returns the start of the day the task is due on,
+// a deadline, a reached counter or a completed task followed earlier than the scheduled day wins,
+// a day out of season is moved to the opening of the season
+func (u *UserTask) NextDue() time.Time {
+	if u.OneOff {
+		return earlierDay(u.DueAt.Truncate(24*time.Hour), u.ChainedAt)
+	}
+	due := earlierDay(u.scheduledDue(), u.DueAt)
+	due = earlierDay(due, u.CounterReachedAt)
+	due = earlierDay(due, u.ChainedAt)
+	return u.seasonStart(due)
+}
+
+// earlierDay returns the start of the day of at if it is set and comes before due
+func earlierDay(due time.Time, at time.Time) time.Time {
+	if !at.IsZero() && at.Before(due) {
+		return at.Truncate(24 * time.Hour)
+	}
+	return due
+}
+
+func (u *UserTask) scheduledDue() time.Time {
+	if u.Anchor != AnchorFixed || u.Regularity <= 0 {
+		return u.LastReminded.Truncate(24 * time.Hour).Add(u.Regularity)
+	}
+	// an occurrence is covered by a completion less than half a period away from it,
+	// so doing the task late or early does not move the schedule
+	start := u.StartAt.Truncate(24 * time.Hour)
+	if u.LastReminded.Before(start) {
+		return start
+	}
+	covered := u.LastReminded.Add(u.Regularity / 2)
+	periods := covered.Sub(start)/u.Regularity + 1
+	return start.Add(periods * u.Regularity)
+}
+
 type TaskStorage interface {
 	CreateEmptyTask(ctx context.Context, chatID int64) (int64, error)
 	CreateTaskName(ctx context.Context, taskID int64, taskName string) error
 	CreateTaskRegularity(ctx context.Context, taskID int64, regularity time.Duration) error
 	FinishCreation(ctx context.Context, taskID int64) error
+	GetTask(ctx context.Context, taskID int64) (UserTask, error)
 	GetTasksForChat(ctx context.Context, chatID int64) ([]UserTask, error)
 	UpdateTask(ctx context.Context, taskUpdate TaskUpdate) error
 	GetChatIDs(ctx context.Context) ([]int64, error)
 	DeleteTask(ctx context.Context, taskID int64) error
+	GetDeletedTasksForChat(ctx context.Context, chatID int64) ([]UserTask, error)
+	RestoreTask(ctx context.Context, taskID int64) error
+	// PurgeDeletedTasks removes tasks deleted before the time with their history
+	PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error)
+	// CreateTaskDueDate makes the task being created a one-off task
+	CreateTaskDueDate(ctx context.Context, taskID int64, dueAt time.Time) error
+	ArchiveTask(ctx context.Context, taskID int64) error
+	AddAttachment(ctx context.Context, attachment Attachment) (int64, error)
+	GetAttachments(ctx context.Context, taskID int64) ([]Attachment, error)
+	DeleteAttachments(ctx context.Context, taskID int64) error
+	SetChecklist(ctx context.Context, taskID int64, items []string) error
+	AddReading(ctx context.Context, reading Reading) (int64, error)
+	// GetReadings returns readings logged after since, the oldest first
+	GetReadings(ctx context.Context, taskID int64, since time.Time) ([]Reading, error)
+	GetChecklist(ctx context.Context, taskID int64) ([]ChecklistItem, error)
+	SetChecklistItemDone(ctx context.Context, itemID int64, done bool) error
+	ResetChecklist(ctx context.Context, taskID int64) error
+	PauseTask(ctx context.Context, taskID int64, at time.Time) error
+	ResumeTask(ctx context.Context, taskID int64) error
+	// ImportChat writes the whole import in one transaction
+	ImportChat(ctx context.Context, batch ImportBatch) error
 }
 
 type TaskUpdate struct {
@@ -52,6 +185,35 @@ type TaskUpdate struct {
 	RemindAfter  *time.Duration
 	Regularity   *time.Duration
 	LastReminded *time.Time
+	Anchor       *AnchorMode
+	StartAt      *time.Time
+	// PausedAt set to zero time resumes the task, pausing keeps the schedule as is
+	PausedAt *time.Time
+	// DueAt set to zero time removes the deadline
+	DueAt  *time.Time
+	OneOff *bool
+	Notes  *string
+
+	RequiresProof *bool
+	Category      *string
+	Tags          *[]string
+	// AfterTaskID zero removes the dependency
+	AfterTaskID *int64
+	AfterDelay  *time.Duration
+	// ChainedAt set to zero time drops the date set by the task followed
+	ChainedAt *time.Time
+
+	Threshold   *float64
+	CounterUnit *string
+	Counter     *float64
+	// CounterReachedAt set to zero time marks the threshold as not reached
+	CounterReachedAt *time.Time
+	Season           *[]regularity.Window
+
+	SuggestedRegularity *time.Duration
+	// SuggestedAt set to zero time marks the offer as not sent
+	SuggestedAt *time.Time
+	Points      *int
 }
 
 type TaskMessageResult string
@@ -76,6 +238,110 @@ func (t TaskMessageResult) IsTaskCreated() bool {
 	return t == "TaskCreated"
 }
 
+func NewNeedRegularityConfirmResult() TaskMessageResult {
+	return "NeedRegularityConfirm"
+}
+
+func (t TaskMessageResult) IsNeedRegularityConfirm() bool {
+	return t == "NeedRegularityConfirm"
+}
+
+func NewNeedDueDateConfirmResult() TaskMessageResult {
+	return "NeedDueDateConfirm"
+}
+
+func (t TaskMessageResult) IsNeedDueDateConfirm() bool {
+	return t == "NeedDueDateConfirm"
+}
+
+func NewNeedDelayConfirmResult() TaskMessageResult {
+	return "NeedDelayConfirm"
+}
+
+func (t TaskMessageResult) IsNeedDelayConfirm() bool {
+	return t == "NeedDelayConfirm"
+}
+
+func NewRegularityParsedResult() TaskMessageResult {
+	return "RegularityParsed"
+}
+
+func (t TaskMessageResult) IsRegularityParsed() bool {
+	return t == "RegularityParsed"
+}
+
+func NewGotEditCategoryTaskResult() TaskMessageResult {
+	return "GotEditCategoryTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditCategoryTaskResult() bool {
+	return t == "GotEditCategoryTaskResult"
+}
+
+func NewGotEditChecklistTaskResult() TaskMessageResult {
+	return "GotEditChecklistTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditChecklistTaskResult() bool {
+	return t == "GotEditChecklistTaskResult"
+}
+
+func NewGotEditDependencyTaskResult() TaskMessageResult {
+	return "GotEditDependencyTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditDependencyTaskResult() bool {
+	return t == "GotEditDependencyTaskResult"
+}
+
+func NewNeedThresholdResult() TaskMessageResult {
+	return "NeedThreshold"
+}
+
+func (t TaskMessageResult) IsNeedThreshold() bool {
+	return t == "NeedThreshold"
+}
+
+func NewGotEditSeasonTaskResult() TaskMessageResult {
+	return "GotEditSeasonTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditSeasonTaskResult() bool {
+	return t == "GotEditSeasonTaskResult"
+}
+
+func NewGotEditPointsTaskResult() TaskMessageResult {
+	return "GotEditPointsTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditPointsTaskResult() bool {
+	return t == "GotEditPointsTaskResult"
+}
+
+func NewNeedTaskKindResult() TaskMessageResult {
+	return "NeedTaskKind"
+}
+
+func (t TaskMessageResult) IsNeedTaskKind() bool {
+	return t == "NeedTaskKind"
+}
+
+func NewNeedRegularityResult() TaskMessageResult {
+	return "NeedRegularity"
+}
+
+func (t TaskMessageResult) IsNeedRegularity() bool {
+	return t == "NeedRegularity"
+}
+
+func NewNeedDueDateResult() TaskMessageResult {
+	return "NeedDueDate"
+}
+
+func (t TaskMessageResult) IsNeedDueDate() bool {
+	return t == "NeedDueDate"
+}
+
 func NewGotEditNumberTaskResult() TaskMessageResult {
 	return "GotEditNumberTaskResult"
 }
@@ -100,6 +366,46 @@ func (t TaskMessageResult) IsGotEditRegularityTaskResult() bool {
 	return t == "GotEditRegularityTaskResult"
 }
 
+func NewGotEditDoneTaskResult() TaskMessageResult {
+	return "GotEditDoneTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditDoneTaskResult() bool {
+	return t == "GotEditDoneTaskResult"
+}
+
+func NewGotEditAnchorTaskResult() TaskMessageResult {
+	return "GotEditAnchorTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditAnchorTaskResult() bool {
+	return t == "GotEditAnchorTaskResult"
+}
+
+func NewGotEditDueDateTaskResult() TaskMessageResult {
+	return "GotEditDueDateTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditDueDateTaskResult() bool {
+	return t == "GotEditDueDateTaskResult"
+}
+
+func NewGotEditNotesTaskResult() TaskMessageResult {
+	return "GotEditNotesTaskResult"
+}
+
+func (t TaskMessageResult) IsGotEditNotesTaskResult() bool {
+	return t == "GotEditNotesTaskResult"
+}
+
+func NewClearedNotesTaskResult() TaskMessageResult {
+	return "ClearedNotesTaskResult"
+}
+
+func (t TaskMessageResult) IsClearedNotesTaskResult() bool {
+	return t == "ClearedNotesTaskResult"
+}
+
 func NewNeedRemindMessageResult() TaskMessageResult {
 	return "NeedRemind"
 }
@@ -126,9 +432,63 @@ type TaskUsecase interface {
 	StartTaskRegularityEdit(ctx context.Context, chatID int64) error
 	StopTaskEdit(ctx context.Context, chatID int64) error
 	ResetTaskEdit(ctx context.Context, chatID int64) error
-	RemindLater(ctx context.Context, chatID int64) error
-	CompleteTask(ctx context.Context, chatID int64) error
-	HandleRemind(ctx context.Context, chatID int64) (TaskMessageResult, error)
-	DeleteCurrentTask(ctx context.Context, chatID int64) error
+	RemindLater(ctx context.Context, chatID int64, member Member) error
+	CompleteTask(ctx context.Context, chatID int64, member Member) error
+	CompleteTaskWithProof(ctx context.Context, chatID int64, member Member, fileID string) error
+	ToggleTaskProof(ctx context.Context, chatID int64) (UserTask, error)
+	GetTaskProofs(ctx context.Context, chatID int64) ([]HistoryRecord, error)
+	SkipTask(ctx context.Context, chatID int64, member Member) (time.Time, error)
+	StartTaskDone(ctx context.Context, chatID int64, member Member) error
+	ToggleTaskAnchor(ctx context.Context, chatID int64) (AnchorMode, error)
+	ChooseTaskKind(ctx context.Context, chatID int64, oneOff bool) error
+	ChooseCounterKind(ctx context.Context, chatID int64) error
+	LogCounter(ctx context.Context, chatID int64, message string) (UserTask, time.Time, error)
+	StartTaskDueDateEdit(ctx context.Context, chatID int64) error
+	StartTaskNotesEdit(ctx context.Context, chatID int64) error
+	GetCategories(ctx context.Context, chatID int64) ([]string, error)
+	StartTaskChecklistEdit(ctx context.Context, chatID int64) error
+	StartTaskDependencyEdit(ctx context.Context, chatID int64) error
+	StartTaskSeasonEdit(ctx context.Context, chatID int64) error
+	StartTaskPointsEdit(ctx context.Context, chatID int64) error
+	GetTaskChain(ctx context.Context, chatID int64) ([]UserTask, error)
+	GetTaskChecklist(ctx context.Context, taskID int64) ([]ChecklistItem, error)
+	ToggleChecklistItem(ctx context.Context, chatID int64, member Member, itemID int64) ([]ChecklistItem, error)
+	StartTaskCategoryChoice(ctx context.Context, chatID int64) error
+	ChooseTaskCategory(ctx context.Context, chatID int64, category string) error
+	StartTaskCategoryEdit(ctx context.Context, chatID int64) error
+	AddTaskAttachment(ctx context.Context, chatID int64, fileID string, kind AttachmentKind) error
+	FinishTaskNotesEdit(ctx context.Context, chatID int64) error
+	GetTaskAttachments(ctx context.Context, taskID int64) ([]Attachment, error)
+	DoneTask(ctx context.Context, chatID int64, member Member, message string) (UserTask, time.Time, error)
+	HandleRemind(ctx context.Context, chatID int64, taskID int64) (TaskMessageResult, error)
+	StartTaskDelete(ctx context.Context, chatID int64) error
+	CancelTaskDelete(ctx context.Context, chatID int64) error
+	DeleteCurrentTask(ctx context.Context, chatID int64) (int64, error)
+	UndoDeleteTask(ctx context.Context, chatID int64, taskID int64) error
+	GetDeletedTasks(ctx context.Context, chatID int64) ([]UserTask, error)
+	RestoreTask(ctx context.Context, chatID int64, taskID int64) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	ToggleTaskPause(ctx context.Context, chatID int64) (UserTask, error)
+	GetChat(ctx context.Context, chatID int64) (Chat, error)
+	StartVacation(ctx context.Context, chatID int64, message string) (time.Time, error)
+	EndVacation(ctx context.Context, chatID int64) (time.Duration, error)
+	SetDigest(ctx context.Context, chatID int64, message string) (DigestSettings, error)
+	BuildDigest(ctx context.Context, chatID int64, now time.Time) (Digest, error)
+	MarkDigestSent(ctx context.Context, chatID int64, at time.Time) error
+	SetQuietHours(ctx context.Context, chatID int64, message string) (QuietHours, error)
+	GetStats(ctx context.Context, chatID int64) (ChatStats, error)
+	TaskChart(ctx context.Context, chatID int64, message string) (Chart, error)
+	MarkSuggestionSent(ctx context.Context, taskID int64, at time.Time) error
+	AnswerSuggestion(ctx context.Context, chatID int64, taskID int64, accept bool) (UserTask, error)
+	GetLeaderboard(ctx context.Context, chatID int64) (Leaderboard, error)
 	StopTaskCreation(ctx context.Context, chatID int64) error
+	CurrentTask(ctx context.Context, chatID int64) (UserTask, error)
+	ConfirmTaskRegularity(ctx context.Context, chatID int64) error
+	RejectTaskRegularity(ctx context.Context, chatID int64) error
+	ExportChat(ctx context.Context, chatID int64) (ChatExport, error)
+	StartImport(ctx context.Context, chatID int64) error
+	PreviewImport(ctx context.Context, chatID int64, fileRef string, export ChatExport) (ImportDiff, error)
+	ImportFileRef(ctx context.Context, chatID int64) (string, error)
+	ApplyImport(ctx context.Context, chatID int64, export ChatExport) (ImportDiff, error)
+	StopImport(ctx context.Context, chatID int64) error
 }
@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDue(t *testing.T) {
+	day := 24 * time.Hour
+	// tuesday
+	start := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		task UserTask
+		due  time.Time
+	}{
+		"from completion": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(2*day + 10*time.Hour)},
+			start.Add(9 * day),
+		},
+		"fixed before start": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(-3 * day), Anchor: AnchorFixed, StartAt: start},
+			start,
+		},
+		"fixed on time": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(12 * time.Hour), Anchor: AnchorFixed, StartAt: start},
+			start.Add(7 * day),
+		},
+		"fixed two days late": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(2*day + 10*time.Hour), Anchor: AnchorFixed, StartAt: start},
+			start.Add(7 * day),
+		},
+		"fixed a day early": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(6 * day), Anchor: AnchorFixed, StartAt: start},
+			start.Add(14 * day),
+		},
+		"fixed second occurrence": {
+			UserTask{Regularity: 7 * day, LastReminded: start.Add(7 * day), Anchor: AnchorFixed, StartAt: start},
+			start.Add(14 * day),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.due, c.task.NextDue().UTC())
+		})
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	// wednesday
+	now := time.Date(2024, time.November, 6, 10, 30, 0, 0, time.UTC)
+	cases := map[string]struct {
+		chat Chat
+		due  bool
+	}{
+		"off": {
+			Chat{},
+			false,
+		},
+		"daily never sent": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 9}},
+			true,
+		},
+		"daily sent today": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 9}, DigestSentAt: now.Add(-time.Hour)},
+			false,
+		},
+		"daily sent yesterday": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 9}, DigestSentAt: now.Add(-25 * time.Hour)},
+			true,
+		},
+		"daily later today": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 11}, DigestSentAt: now.Add(-20 * time.Hour)},
+			false,
+		},
+		"weekly sent on monday": {
+			Chat{Digest: DigestSettings{Period: DigestWeekly, Hour: 9, Weekday: time.Monday}, DigestSentAt: now.Add(-49 * time.Hour)},
+			false,
+		},
+		"weekly sent last week": {
+			Chat{Digest: DigestSettings{Period: DigestWeekly, Hour: 9, Weekday: time.Monday}, DigestSentAt: now.Add(-6 * 24 * time.Hour)},
+			true,
+		},
+		// 10:30 UTC is 13:30 in Moscow
+		"daily in chat time zone": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 13}, Quiet: QuietHours{Offset: 3 * time.Hour}, DigestSentAt: now.Add(-time.Hour)},
+			true,
+		},
+		"daily later in chat time zone": {
+			Chat{Digest: DigestSettings{Period: DigestDaily, Hour: 14}, Quiet: QuietHours{Offset: 3 * time.Hour}, DigestSentAt: now.Add(-time.Hour)},
+			false,
+		},
+		"weekly today later": {
+			Chat{Digest: DigestSettings{Period: DigestWeekly, Hour: 12, Weekday: time.Wednesday}, DigestSentAt: now.Add(-6 * 24 * time.Hour)},
+			false,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.due, c.chat.DigestDue(now))
+		})
+	}
+}
+
+func TestQuietHoursDefer(t *testing.T) {
+	// friday
+	day := time.Date(2024, time.November, 8, 0, 0, 0, 0, time.UTC)
+	night := QuietHours{From: 22 * time.Hour, Until: 8 * time.Hour, WeekendUntil: 11 * time.Hour}
+	moscow := QuietHours{From: 22 * time.Hour, Until: 8 * time.Hour, Offset: 3 * time.Hour}
+	nap := QuietHours{From: 13 * time.Hour, Until: 15 * time.Hour}
+	cases := map[string]struct {
+		quiet QuietHours
+		t     time.Time
+		res   time.Time
+	}{
+		"disabled":       {QuietHours{}, day.Add(3 * time.Hour), day.Add(3 * time.Hour)},
+		"day":            {night, day.Add(15 * time.Hour), day.Add(15 * time.Hour)},
+		"morning":        {night, day.Add(3 * time.Hour), day.Add(8 * time.Hour)},
+		"window end":     {night, day.Add(8 * time.Hour), day.Add(8 * time.Hour)},
+		"evening":        {night, day.Add(23 * time.Hour), day.Add(24*time.Hour + 11*time.Hour)},
+		"saturday":       {night, day.Add(24*time.Hour + 9*time.Hour), day.Add(24*time.Hour + 11*time.Hour)},
+		"sunday evening": {night, day.Add(2*24*time.Hour + 22*time.Hour), day.Add(3*24*time.Hour + 8*time.Hour)},
+		"moscow night":   {moscow, day.Add(20 * time.Hour), day.Add(24*time.Hour + 5*time.Hour)},
+		"moscow morning": {moscow, day.Add(4 * time.Hour), day.Add(5 * time.Hour)},
+		"moscow day":     {moscow, day.Add(6 * time.Hour), day.Add(6 * time.Hour)},
+		"nap":            {nap, day.Add(14 * time.Hour), day.Add(15 * time.Hour)},
+		"before nap":     {nap, day.Add(12 * time.Hour), day.Add(12 * time.Hour)},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.res, c.quiet.Defer(c.t).UTC())
+			assert.Equal(t, c.res != c.t, c.quiet.Quiet(c.t))
+		})
+	}
+}
+
+func TestStepType(t *testing.T) {
+	assert.Equal(t, TaskCreationEvent, TaskCreationWaitName.GetType())
+	assert.Equal(t, TaskCreationEvent, TaskCreationWaitThreshold.GetType())
+	assert.Equal(t, TaskEditEvent, TaskEditSeason.GetType())
+	assert.Equal(t, TaskRemindEvent, TaskRemindWaitProof.GetType())
+	assert.Equal(t, TaskImportEvent, TaskImportConfirm.GetType())
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "errors"
+
+// Errors below are returned by TaskUsecase for the bot handlers to answer them
+
+var ErrProofRequired = errors.New("task requires proof")
+
+var ErrNoSuggestion = errors.New("task has no regularity suggestion")
+
+// ErrReminderClosed is returned for a button of a reminder that is already answered
+var ErrReminderClosed = errors.New("reminder is closed")
+
+// ErrNoSchedule is returned for skipping a recurring task without a regularity
+var ErrNoSchedule = errors.New("task has no schedule")
@@ -13,32 +13,68 @@ const (
 	TaskEditEvent     TaskEventType = "task_edit"
 
 	TaskRemindEvent TaskEventType = "task_remind"
+
+	TaskImportEvent TaskEventType = "task_import"
 )
 
 func (t TaskEventStep) GetType() TaskEventType {
 	switch t {
-	case TaskCreationWaitName:
-	case TaskCreationWaitRegularity:
-	case TaskCreationCompleted:
+	case TaskCreationWaitName, TaskCreationWaitCategory, TaskCreationWaitKind, TaskCreationWaitRegularity,
+		TaskCreationWaitDueDate, TaskCreationWaitThreshold, TaskCreationConfirmRegularity, TaskCreationCompleted:
 		return TaskCreationEvent
+	case TaskRemindWait, TaskRemindWaitProof:
+		return TaskRemindEvent
+	case TaskImportWaitFile, TaskImportConfirm:
+		return TaskImportEvent
 	}
 	return TaskEditEvent
 }
 
 const (
-	TaskCreationWaitName       TaskEventStep = "task_creation_wait_name"
-	TaskCreationWaitRegularity TaskEventStep = "task_creation_wait_regularity"
-	TaskCreationCompleted      TaskEventStep = "task_creation_completed"
+	TaskCreationWaitName          TaskEventStep = "task_creation_wait_name"
+	TaskCreationWaitCategory      TaskEventStep = "task_creation_wait_category"
+	TaskCreationWaitKind          TaskEventStep = "task_creation_wait_kind"
+	TaskCreationWaitDueDate       TaskEventStep = "task_creation_wait_due_date"
+	TaskCreationWaitThreshold     TaskEventStep = "task_creation_wait_threshold"
+	TaskCreationWaitRegularity    TaskEventStep = "task_creation_wait_regularity"
+	TaskCreationConfirmRegularity TaskEventStep = "task_creation_confirm_regularity"
+	TaskCreationCompleted         TaskEventStep = "task_creation_completed"
 
 	TaskEditGetNumber        TaskEventStep = "task_edit_get_number"
 	TaskEditChangeName       TaskEventStep = "task_edit_wait_name"
 	TaskEditWait             TaskEventStep = "task_edit_wait"
 	TaskEditChangeRegularity TaskEventStep = "task_edit_wait_regularity"
+	TaskEditConfirmDelete    TaskEventStep = "task_edit_confirm_delete"
+	TaskEditDoneDate         TaskEventStep = "task_edit_wait_done_date"
+	TaskEditAnchorDate       TaskEventStep = "task_edit_wait_anchor_date"
+	TaskEditDueDate          TaskEventStep = "task_edit_wait_due_date"
+	TaskEditNotes            TaskEventStep = "task_edit_wait_notes"
+	TaskEditCategory         TaskEventStep = "task_edit_wait_category"
+	TaskEditChecklist        TaskEventStep = "task_edit_wait_checklist"
+	TaskEditDependency       TaskEventStep = "task_edit_wait_dependency"
+	TaskEditSeason           TaskEventStep = "task_edit_wait_season"
+	TaskEditPoints           TaskEventStep = "task_edit_wait_points"
 	TaskEditCompleted        TaskEventStep = "task_edit_completed"
 
-	TaskRemindWait TaskEventStep = "task_remind_wait"
+	TaskRemindWait      TaskEventStep = "task_remind_wait"
+	TaskRemindWaitProof TaskEventStep = "task_remind_wait_proof"
+
+	TaskImportWaitFile TaskEventStep = "task_import_wait_file"
+	TaskImportConfirm  TaskEventStep = "task_import_confirm"
 )
 
+// EditStoppable reports whether the task edit can be stopped at the step,
+// renaming and changing the regularity are finished by their answer
+func (t TaskEventStep) EditStoppable() bool {
+	switch t {
+	case TaskEditGetNumber, TaskEditWait, TaskEditConfirmDelete, TaskEditDoneDate,
+		TaskEditAnchorDate, TaskEditDueDate, TaskEditNotes, TaskEditCategory,
+		TaskEditChecklist, TaskEditDependency, TaskEditSeason, TaskEditPoints:
+		return true
+	}
+	return false
+}
+
 // TaskEvent only one active task_event per chat
 type TaskEvent struct {
 	DBEntity
@@ -55,6 +91,8 @@ type UserTaskEvent struct {
 	Step   TaskEventStep
 	TaskID int64
 	ChatID int64
+	// Payload is step specific data, e.g. file to import
+	Payload string
 }
 
 type TaskEventStorage interface {
@@ -63,4 +101,5 @@ type TaskEventStorage interface {
 	GetCurrentTaskEvent(ctx context.Context, chatID int64) (UserTaskEvent, error)
 	DeleteEvent(ctx context.Context, eventID int64) error
 	UpdateStep(ctx context.Context, chatID int64, newStep TaskEventStep) error
+	SetPayload(ctx context.Context, eventID int64, payload string) error
 }
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+type HistoryKind string
+
+const (
+	HistoryCompleted HistoryKind = "completed"
+	// HistorySkipped is an occurrence that was not needed, it is not a completion
+	HistorySkipped HistoryKind = "skipped"
+)
+
+// HistoryRecord is something that happened to a task, e.g. its completion
+type HistoryRecord struct {
+	ID     int64
+	ChatID int64
+	TaskID int64
+	Kind   HistoryKind
+	DoneAt time.Time
+	// ProofFileID is a telegram file id of the photo sent on completion
+	ProofFileID string
+	// UserID and UserName are of the member who pressed the button, zero when unknown
+	UserID   int64
+	UserName string
+	// Points are awarded to the member for a completion
+	Points int
+}
+
+// Snooze is a reminder put off with "remind later", it is not an occurrence of the task
+// so it is kept apart from the history
+type Snooze struct {
+	ID        int64
+	ChatID    int64
+	TaskID    int64
+	SnoozedAt time.Time
+	UserID    int64
+	UserName  string
+}
+
+type HistoryStorage interface {
+	AddRecord(ctx context.Context, record HistoryRecord) (int64, error)
+	AddSnooze(ctx context.Context, snooze Snooze) (int64, error)
+	// GetChatSnoozes returns snoozes ordered by SnoozedAt
+	GetChatSnoozes(ctx context.Context, chatID int64) ([]Snooze, error)
+	// GetChatHistory returns records ordered by DoneAt
+	GetChatHistory(ctx context.Context, chatID int64) ([]HistoryRecord, error)
+	// GetTaskProofs returns records with proof, the latest first
+	GetTaskProofs(ctx context.Context, taskID int64, limit int) ([]HistoryRecord, error)
+}
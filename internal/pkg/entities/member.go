@@ -0,0 +1,18 @@
+package entities
+
+import "strings"
+
+// Member is a user of a group chat who does the chores
+type Member struct {
+	ID   int64
+	Name string
+}
+
+// NewMember names the member by the full name or the username if there is none
+func NewMember(id int64, firstName string, lastName string, userName string) Member {
+	name := strings.TrimSpace(firstName + " " + lastName)
+	if name == "" {
+		name = userName
+	}
+	return Member{ID: id, Name: name}
+}
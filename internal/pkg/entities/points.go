@@ -0,0 +1,49 @@
+package entities
+
+import "time"
+
+// MaxTaskPoints keeps the leaderboard sane
+const MaxTaskPoints = 100
+
+// DefaultPoints grow with the regularity, rare chores are bigger ones
+func (u *UserTask) DefaultPoints() int {
+	if u.OneOff {
+		return 3
+	}
+	day := 24 * time.Hour
+	switch {
+	case u.Regularity <= day:
+		return 1
+	case u.Regularity <= 3*day:
+		return 2
+	case u.Regularity <= 7*day:
+		return 3
+	case u.Regularity <= 14*day:
+		return 4
+	case u.Regularity <= 31*day:
+		return 5
+	case u.Regularity <= 92*day:
+		return 8
+	default:
+		return 10
+	}
+}
+
+// TaskPoints returns the configured points or the default ones
+func (u *UserTask) TaskPoints() int {
+	if u.Points > 0 {
+		return u.Points
+	}
+	return u.DefaultPoints()
+}
+
+type MemberPoints struct {
+	Member Member
+	Points int
+}
+
+// Leaderboard ranks members of the chat by points, the best first
+type Leaderboard struct {
+	Week    []MemberPoints
+	AllTime []MemberPoints
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+// QuietHours is a daily window when the chat gets no reminders, times are from the local midnight,
+// the window wraps around midnight when Until is before From
+type QuietHours struct {
+	From  time.Duration
+	Until time.Duration
+	// WeekendUntil ends the window later on saturday and sunday mornings, zero for the same as Until
+	WeekendUntil time.Duration
+	// Offset is the local time zone of the chat from UTC
+	Offset time.Duration
+}
+
+// MaxUTCOffset bounds the time zone of a chat both ways
+const MaxUTCOffset = 14 * time.Hour
+
+func (q QuietHours) Enabled() bool {
+	return q.From != q.Until
+}
+
+// until returns the end of the window on the local day
+func (q QuietHours) until(day time.Time) time.Duration {
+	weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+	if weekend && q.WeekendUntil != 0 {
+		return q.WeekendUntil
+	}
+	return q.Until
+}
+
+// Defer returns the end of the window if t is inside it and t otherwise
+func (q QuietHours) Defer(t time.Time) time.Time {
+	if !q.Enabled() {
+		return t
+	}
+	local := t.UTC().Add(q.Offset)
+	day := local.Truncate(24 * time.Hour)
+	sinceMidnight := local.Sub(day)
+	end := time.Time{}
+	if sinceMidnight < q.until(day) && (q.From > q.Until || sinceMidnight >= q.From) {
+		end = day.Add(q.until(day))
+	} else if q.From > q.Until && sinceMidnight >= q.From {
+		next := day.Add(24 * time.Hour)
+		end = next.Add(q.until(next))
+	}
+	if end.IsZero() {
+		return t
+	}
+	return end.Add(-q.Offset)
+}
+
+// Quiet reports whether t is inside the window
+func (q QuietHours) Quiet(t time.Time) bool {
+	return q.Defer(t).After(t)
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+)
+
+// InSeason reports whether t is in any window of the task, tasks without windows are always active
+func (u *UserTask) InSeason(t time.Time) bool {
+	if len(u.Season) == 0 {
+		return true
+	}
+	for _, w := range u.Season {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// seasonStart moves t to the nearest window opening if it is out of season
+func (u *UserTask) seasonStart(t time.Time) time.Time {
+	if u.InSeason(t) {
+		return t
+	}
+	var res time.Time
+	for _, w := range u.Season {
+		if open := w.Opening(t); res.IsZero() || open.Before(res) {
+			res = open
+		}
+	}
+	return res
+}
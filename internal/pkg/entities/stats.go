@@ -0,0 +1,41 @@
+package entities
+
+import "time"
+
+// TaskStats are computed from the history of a task
+type TaskStats struct {
+	Task      UserTask
+	Completed int
+	// Rated are completions after a previous occurrence, only they can be on time or late
+	Rated  int
+	OnTime int
+	// AvgDelay is the mean of time between occurrences minus Regularity, negative when done early
+	AvgDelay      time.Duration
+	Skipped       int
+	Snoozed       int
+	CurrentStreak int
+	BestStreak    int
+}
+
+// OnTimeRate is the share of rated completions done on time, -1 when nothing is rated
+func (s TaskStats) OnTimeRate() float64 {
+	if s.Rated == 0 {
+		return -1
+	}
+	return float64(s.OnTime) / float64(s.Rated)
+}
+
+type MemberStats struct {
+	Member    Member
+	Completed int
+	// Share is the part of all completions of the chat
+	Share float64
+}
+
+type ChatStats struct {
+	Tasks     []TaskStats
+	Members   []MemberStats
+	Completed int
+	Skipped   int
+	Snoozed   int
+}
@@ -0,0 +1,126 @@
+package entities
+
+import (
+	"time"
+)
+
+// ChatExport is everything about a chat that can be moved to another bot instance
+type ChatExport struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Settings   ExportedSettings `json:"settings"`
+	Tasks      []ExportedTask   `json:"tasks"`
+	History    []ExportedRecord `json:"history"`
+}
+
+type ExportedSettings struct {
+	// ICalToken keeps calendar subscriptions working after moving
+	ICalToken string `json:"ical_token,omitempty"`
+	// Settings below are nil in csv and older exports, nil keeps the current ones on import
+	// Vacation has zero times when the chat is not on vacation
+	Vacation *ExportedVacation `json:"vacation,omitempty"`
+	Digest   *ExportedDigest   `json:"digest,omitempty"`
+	Quiet    *ExportedQuiet    `json:"quiet,omitempty"`
+}
+
+type ExportedVacation struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+}
+
+// ExportedDigest has an empty period when the digest is off, the hour is in the chat time zone
+type ExportedDigest struct {
+	Period  DigestPeriod `json:"period"`
+	Hour    int          `json:"hour"`
+	Weekday time.Weekday `json:"weekday"`
+	Only    bool         `json:"only,omitempty"`
+}
+
+// ExportedQuiet keeps the quiet hours from the local midnight and the time zone of the chat
+type ExportedQuiet struct {
+	FromSeconds         int64 `json:"from_seconds"`
+	UntilSeconds        int64 `json:"until_seconds"`
+	WeekendUntilSeconds int64 `json:"weekend_until_seconds,omitempty"`
+	OffsetSeconds       int64 `json:"offset_seconds"`
+}
+
+type ExportedTask struct {
+	// ID is only used to link history records inside the export
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	RegularitySeconds  int64     `json:"regularity_seconds"`
+	LastReminded       time.Time `json:"last_reminded"`
+	RemindAfterSeconds int64     `json:"remind_after_seconds"`
+	// OneOff tasks have zero regularity and DueAt
+	OneOff bool       `json:"one_off,omitempty"`
+	DueAt  *time.Time `json:"due_at,omitempty"`
+	// Fields below are nil in csv and older exports, nil keeps the current value on import,
+	// an empty one clears it
+	// Notes are exported without attachments, file ids are only valid for the same bot
+	Notes    *string   `json:"notes,omitempty"`
+	Category *string   `json:"category,omitempty"`
+	Tags     *[]string `json:"tags,omitempty"`
+	// Threshold is zero for tasks due by time only, counter fields are imported with it
+	Threshold   *float64 `json:"threshold,omitempty"`
+	CounterUnit string   `json:"counter_unit,omitempty"`
+	Counter     float64  `json:"counter,omitempty"`
+	// Season is written as "04-01:10-31,12-01:01-15"
+	Season *string `json:"season,omitempty"`
+	// Points are zero for the default ones following the regularity
+	Points *int `json:"points,omitempty"`
+	// Anchor is imported with AnchorAt, the first occurrence of fixed schedules
+	Anchor   *AnchorMode `json:"anchor,omitempty"`
+	AnchorAt *time.Time  `json:"anchor_at,omitempty"`
+	// PausedAt is zero for active tasks
+	PausedAt      *time.Time `json:"paused_at,omitempty"`
+	RequiresProof *bool      `json:"requires_proof,omitempty"`
+	// Checklist items are imported unticked
+	Checklist *[]string `json:"checklist,omitempty"`
+	// After is the name of the task followed, empty for independent tasks,
+	// the delay and the date set by its completion are imported with it
+	After             *string    `json:"after,omitempty"`
+	AfterDelaySeconds int64      `json:"after_delay_seconds,omitempty"`
+	ChainedAt         *time.Time `json:"chained_at,omitempty"`
+	// CounterReachedAt is imported with Threshold
+	CounterReachedAt *time.Time `json:"counter_reached_at,omitempty"`
+}
+
+type ExportedRecord struct {
+	TaskID   int64       `json:"task_id"`
+	Kind     HistoryKind `json:"kind"`
+	DoneAt   time.Time   `json:"done_at"`
+	UserID   int64       `json:"user_id,omitempty"`
+	UserName string      `json:"user_name,omitempty"`
+	Points   int         `json:"points,omitempty"`
+}
+
+// ImportedTask is a task written by import with its new history records,
+// zero Update.TaskID creates the task
+type ImportedTask struct {
+	Update  TaskUpdate
+	History []HistoryRecord
+	// Checklist is nil to keep the current one
+	Checklist *[]string
+	// After is the position in the batch of the task followed, nil keeps the dependency
+	// and -1 removes it, Update.AfterDelay is set with it
+	After *int
+}
+
+type ImportBatch struct {
+	ChatID int64
+	Tasks  []ImportedTask
+	// ICalToken is set when the chat has none and no other chat uses it
+	ICalToken string
+	// Settings below are nil to keep the current ones, zero Vacation ends the current one
+	Vacation *ExportedVacation
+	Digest   *DigestSettings
+	Quiet    *QuietHours
+}
+
+// ImportDiff describes what import is going to change, tasks are matched by name
+type ImportDiff struct {
+	Created    []string
+	Updated    []string
+	Unchanged  []string
+	NewHistory int
+}
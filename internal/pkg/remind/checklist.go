@@ -0,0 +1,75 @@
+package remind
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+// remindMenu puts checklist toggles of the task above the usual remind buttons
+func (r *remindHanlder) remindMenu(ctx context.Context, taskID int64) (*tele.ReplyMarkup, error) {
+	items, err := r.taskUsecase.GetTaskChecklist(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return r.menu, nil
+	}
+	return r.checklistMenu(items), nil
+}
+
+func (r *remindHanlder) checklistMenu(items []entities.ChecklistItem) *tele.ReplyMarkup {
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	for _, item := range items {
+		mark := "☐ "
+		if item.Done {
+			mark = "✅ "
+		}
+		rows = append(rows, menu.Row(menu.Data(mark+item.Text, r.btnItem.Unique, strconv.FormatInt(item.ID, 10))))
+	}
+	menu.Inline(append(rows, r.menuRows...)...)
+	return menu
+}
+
+func (r *remindHanlder) handleChecklistItem(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	itemID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		log.Error(err, "bad checklist item data", "data", c.Data())
+		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
+	}
+	items, err := r.taskUsecase.ToggleChecklistItem(ctx, chatID, senderMember(c), itemID)
+	if errors.Is(err, entities.ErrProofRequired) {
+		return c.Send("Все пункты готовы, пришлите фото выполненной задачи")
+	}
+	if err != nil {
+		if errors.Is(err, entities.ErrReminderClosed) {
+			return c.Respond(&tele.CallbackResponse{Text: "Это напоминание уже закрыто"})
+		}
+		log.Error(err, "failed to toggle checklist item")
+		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
+	}
+	// answer the callback so the button stops spinning
+	err = c.Respond()
+	if err != nil {
+		log.Error(err, "failed to respond to checklist toggle")
+	}
+	if entities.ChecklistDone(items) {
+		err = c.Edit(c.Message().Text)
+		if err != nil {
+			log.Error(err, "failed to remove checklist")
+		}
+		return c.Send("Все пункты готовы, задача выполнена. Молодец огурец")
+	}
+	return c.Edit(c.Message().Text, r.checklistMenu(items))
+}
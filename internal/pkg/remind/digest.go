@@ -0,0 +1,88 @@
+package remind
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+func formatDigest(digest entities.Digest) string {
+	if digest.Empty() {
+		return "Сводка: задач нет, можно отдыхать"
+	}
+	upcoming, done := "Сегодня", "Вчера"
+	if digest.Period == entities.DigestWeekly {
+		upcoming, done = "На этой неделе", "На прошлой неделе"
+	}
+	var b strings.Builder
+	b.WriteString("Сводка по задачам\n")
+	if len(digest.Overdue) > 0 {
+		b.WriteString("\nПросрочено:\n")
+		for _, task := range digest.Overdue {
+			fmt.Fprintf(&b, "• %s (с %s)\n", task.Name, task.NextDue().Format("02.01"))
+		}
+	}
+	if len(digest.Upcoming) > 0 {
+		b.WriteString("\n" + upcoming + ":\n")
+		for _, task := range digest.Upcoming {
+			fmt.Fprintf(&b, "• %s (%s)\n", task.Name, task.NextDue().Format("02.01"))
+		}
+	}
+	if len(digest.Done) > 0 {
+		b.WriteString("\n" + done + " сделано:\n")
+		for _, done := range digest.Done {
+			if done.Times > 1 {
+				fmt.Fprintf(&b, "• %s ×%d\n", done.Task.Name, done.Times)
+			} else {
+				fmt.Fprintf(&b, "• %s\n", done.Task.Name)
+			}
+		}
+	}
+	if len(digest.Overdue)+len(digest.Upcoming) > 0 {
+		b.WriteString("\nОтметить выполненное: /done название")
+	}
+	return b.String()
+}
+
+// sendDigests sends digests to chats whose time came, chats on vacation get none
+// and chats in quiet hours get it when they are over
+func (r *remindHanlder) sendDigests(ctx context.Context, log logr.Logger, now time.Time) {
+	chats, err := r.taskRepo.GetChatIDs(ctx)
+	if err != nil {
+		log.Error(err, "failed to get chats")
+		return
+	}
+	for _, chatID := range chats {
+		chat, err := r.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat", "chatID", chatID)
+			continue
+		}
+		if !chat.DigestDue(now) || chat.OnVacation(now) || chat.Quiet.Quiet(now) {
+			continue
+		}
+		digest, err := r.taskUsecase.BuildDigest(ctx, chatID, now)
+		if err != nil {
+			log.Error(err, "failed to build digest", "chatID", chatID)
+			continue
+		}
+		// marked before sending so a failing chat is not spammed every minute
+		err = r.taskUsecase.MarkDigestSent(ctx, chatID, now)
+		if err != nil {
+			log.Error(err, "failed to mark digest sent", "chatID", chatID)
+			continue
+		}
+		_, err = r.bot.Send(&tele.User{ID: chatID}, formatDigest(digest))
+		if err != nil {
+			log.Error(err, "failed to send digest", "chatID", chatID)
+			continue
+		}
+		log.Info("sent digest", "chatID", chatID)
+	}
+}
@@ -2,8 +2,10 @@ package remind
 
 import (
 	"context"
+	"errors"
 	"house-timer/internal/pkg/entities"
 	"house-timer/internal/pkg/logmw"
+	"house-timer/pkg/regularity"
 	"log"
 	"log/slog"
 	"time"
@@ -17,7 +19,12 @@ type remindHanlder struct {
 	taskUsecase entities.TaskUsecase
 	bot         *tele.Bot
 	menu        *tele.ReplyMarkup
-	logger      logr.Logger
+	menuRows    []tele.Row
+	btnItem     tele.Btn
+	// suggestion buttons have task id in data
+	btnSuggestAccept tele.Btn
+	btnSuggestKeep   tele.Btn
+	logger           logr.Logger
 }
 
 func NewRemindHandler(taskRepo entities.TaskStorage, taskUsecase entities.TaskUsecase, bot *tele.Bot) *remindHanlder {
@@ -31,25 +38,52 @@ func NewRemindHandler(taskRepo entities.TaskStorage, taskUsecase entities.TaskUs
 	remindMenu := &tele.ReplyMarkup{}
 	btnTaskComplete := remindMenu.Data("Задача выполнена", "taskComplete")
 	btnRemindAfter := remindMenu.Data("Напомнить позже", "remindAfter")
+	btnSkip := remindMenu.Data("Пропустить", "remindSkip")
 
-	remindMenu.Inline(
+	menuRows := []tele.Row{
 		remindMenu.Row(btnTaskComplete),
 		remindMenu.Row(btnRemindAfter),
-	)
+		remindMenu.Row(btnSkip),
+	}
+	remindMenu.Inline(menuRows...)
+	// checklist buttons with item id in data are made per message
+	btnItem := tele.Btn{Unique: "checklistItem"}
+	btnSuggestAccept := tele.Btn{Unique: "suggestAccept"}
+	btnSuggestKeep := tele.Btn{Unique: "suggestKeep"}
 
 	bot.Use(logmw.NewLogMW(r.logger))
 	bot.Handle(&btnTaskComplete, r.handleTaskComplete)
 	bot.Handle(&btnRemindAfter, r.handleRemindAfter)
+	bot.Handle(&btnSkip, r.handleSkip)
+	bot.Handle(&btnItem, r.handleChecklistItem)
+	bot.Handle(&btnSuggestAccept, r.handleSuggestAccept)
+	bot.Handle(&btnSuggestKeep, r.handleSuggestKeep)
 
 	r.menu = remindMenu
+	r.menuRows = menuRows
+	r.btnItem = btnItem
+	r.btnSuggestAccept = btnSuggestAccept
+	r.btnSuggestKeep = btnSuggestKeep
 
 	return r
 }
 
+// senderMember returns the user who pressed the button
+func senderMember(c tele.Context) entities.Member {
+	sender := c.Sender()
+	if sender == nil {
+		return entities.Member{}
+	}
+	return entities.NewMember(sender.ID, sender.FirstName, sender.LastName, sender.Username)
+}
+
 func (r *remindHanlder) handleTaskComplete(c tele.Context) error {
 	chatID := c.Chat().ID
 	log := logmw.GetLogger(c)
-	err := r.taskUsecase.CompleteTask(context.Background(), chatID)
+	err := r.taskUsecase.CompleteTask(context.Background(), chatID, senderMember(c))
+	if errors.Is(err, entities.ErrProofRequired) {
+		return c.Send("Пришлите фото выполненной задачи")
+	}
 	if err != nil {
 		log.Error(err, "failed to complete task")
 		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
@@ -60,7 +94,7 @@ func (r *remindHanlder) handleTaskComplete(c tele.Context) error {
 func (r *remindHanlder) handleRemindAfter(c tele.Context) error {
 	chatID := c.Chat().ID
 	log := logmw.GetLogger(c)
-	err := r.taskUsecase.RemindLater(context.Background(), chatID)
+	err := r.taskUsecase.RemindLater(context.Background(), chatID, senderMember(c))
 	if err != nil {
 		log.Error(err, "failed to remind later")
 		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
@@ -68,50 +102,161 @@ func (r *remindHanlder) handleRemindAfter(c tele.Context) error {
 	return c.Send("Ок, напомню завтра")
 }
 
+func (r *remindHanlder) handleSkip(c tele.Context) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	next, err := r.taskUsecase.SkipTask(context.Background(), chatID, senderMember(c))
+	if errors.Is(err, entities.ErrNoSchedule) {
+		return c.Send("У задачи нет расписания, пропускать нечего")
+	}
+	if err != nil {
+		log.Error(err, "failed to skip task")
+		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
+	}
+	if next.IsZero() {
+		return c.Send("Ок, пропускаем, больше не напомню")
+	}
+	return c.Send("Ок, пропускаем, в следующий раз напомню " + next.Format("02.01"))
+}
+
+// needsRemind keeps quiet out of season, the due day of a task is moved to the opening of its season
 func needsRemind(now time.Time, task entities.UserTask) bool {
-	remindTime := task.LastReminded.Truncate(24 * time.Hour).Add(task.Regularity).Add(task.RemindAfter).Add(15 * time.Hour)
-	return now.After(remindTime)
+	return !task.Paused() && task.InSeason(now) && now.After(task.NextRemind())
+}
+
+// loopLogger is the logger of the remind loop run at now
+func (r *remindHanlder) loopLogger(now time.Time) logr.Logger {
+	return r.logger.WithName("remind loop").WithValues("id", now.Unix())
 }
 
-func (r *remindHanlder) remindTasks(ctx context.Context) {
-	log := r.logger.WithName("remind loop").WithValues("id", time.Now().Unix())
+// DueTasks returns tasks of all chats that need to be reminded at now,
+// chats failing to load are logged and skipped so they do not stop reminders of the others
+func (r *remindHanlder) DueTasks(ctx context.Context, now time.Time) ([]entities.UserTask, error) {
+	log := r.loopLogger(now)
 	chats, err := r.taskRepo.GetChatIDs(ctx)
 	if err != nil {
-		log.Error(err, "failed to get chat ids")
-		return
+		return nil, err
 	}
+	var res []entities.UserTask
 	for _, chat := range chats {
-		log.Info("reminding tasks for chat", "chat", chat)
+		settings, err := r.taskUsecase.GetChat(ctx, chat)
+		if err != nil {
+			log.Error(err, "failed to get chat", "chatID", chat)
+			continue
+		}
+		// the digest lists due tasks instead, reminders in quiet hours wait for their end
+		if settings.OnVacation(now) || settings.Digest.Only || settings.Quiet.Quiet(now) {
+			continue
+		}
 		tasks, err := r.taskRepo.GetTasksForChat(ctx, chat)
 		if err != nil {
-			log.Error(err, "failed to get tasks for chat", "chat", chat)
-			return
+			log.Error(err, "failed to get tasks", "chatID", chat)
+			continue
 		}
-		now := time.Now()
 		for _, task := range tasks {
 			if needsRemind(now, task) {
-				res, err := r.taskUsecase.HandleRemind(ctx, task.ChatID)
-				if err != nil {
-					log.Error(err, "failed to handle remind", "task", task)
-					continue
-				}
-				if res.IsNoRemindMessageResult() {
-					_, err = r.bot.Send(&tele.User{ID: task.ChatID}, "Заканчивай, хочу напомнить тебе "+task.Name)
-					if err != nil {
-						log.Error(err, "failed to send remind message", "taskID", task.ID)
-					}
-				} else if res.IsNeedRemindMessageResult() {
-					_, err := r.bot.Send(&tele.User{ID: task.ChatID}, "Пора "+task.Name, r.menu)
-					if err != nil {
-						log.Error(err, "failed to send remind message with menu", "taskID", task.ID)
-					}
-				} else {
-					log.Error(nil, "unexpected remind result", "result", res)
-				}
-				log.Info("reminded task", "taskID", task.ID)
+				res = append(res, task)
 			}
 		}
 	}
+	return res, nil
+}
+
+func (r *remindHanlder) remindTask(ctx context.Context, log logr.Logger, task entities.UserTask) {
+	res, err := r.taskUsecase.HandleRemind(ctx, task.ChatID, task.ID)
+	if err != nil {
+		log.Error(err, "failed to handle remind", "task", task)
+		return
+	}
+	if res.IsNoRemindMessageResult() {
+		_, err = r.bot.Send(&tele.User{ID: task.ChatID}, "Заканчивай, хочу напомнить тебе "+task.Name)
+		if err != nil {
+			log.Error(err, "failed to send remind message", "taskID", task.ID)
+		}
+	} else if res.IsNeedRemindMessageResult() {
+		message := "Пора " + task.Name
+		if task.Notes != "" {
+			message += "\n\n" + task.Notes
+		}
+		menu, err := r.remindMenu(ctx, task.ID)
+		if err != nil {
+			log.Error(err, "failed to make remind menu", "taskID", task.ID)
+			menu = r.menu
+		}
+		_, err = r.bot.Send(&tele.User{ID: task.ChatID}, message, menu)
+		if err != nil {
+			log.Error(err, "failed to send remind message with menu", "taskID", task.ID)
+		}
+		r.sendAttachments(ctx, log, task)
+	} else {
+		log.Error(nil, "unexpected remind result", "result", res)
+	}
+	log.Info("reminded task", "taskID", task.ID)
+}
+
+// sendAttachments sends files attached to the task after the remind message
+func (r *remindHanlder) sendAttachments(ctx context.Context, log logr.Logger, task entities.UserTask) {
+	attachments, err := r.taskUsecase.GetTaskAttachments(ctx, task.ID)
+	if err != nil {
+		log.Error(err, "failed to get attachments", "taskID", task.ID)
+		return
+	}
+	for _, attachment := range attachments {
+		var message tele.Sendable = &tele.Document{File: tele.File{FileID: attachment.FileID}}
+		if attachment.Kind == entities.AttachmentPhoto {
+			message = &tele.Photo{File: tele.File{FileID: attachment.FileID}}
+		}
+		_, err = r.bot.Send(&tele.User{ID: task.ChatID}, message)
+		if err != nil {
+			log.Error(err, "failed to send attachment", "taskID", task.ID, "attachmentID", attachment.ID)
+		}
+	}
+}
+
+// endVacations closes vacations that are over so tasks are shifted before reminding
+func (r *remindHanlder) endVacations(ctx context.Context, log logr.Logger, now time.Time) {
+	chats, err := r.taskRepo.GetChatIDs(ctx)
+	if err != nil {
+		log.Error(err, "failed to get chats")
+		return
+	}
+	for _, chatID := range chats {
+		chat, err := r.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat", "chatID", chatID)
+			continue
+		}
+		if !chat.VacationOver(now) {
+			continue
+		}
+		shift, err := r.taskUsecase.EndVacation(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to end vacation", "chatID", chatID)
+			continue
+		}
+		_, err = r.bot.Send(&tele.User{ID: chatID}, "С возвращением! Отпуск закончился, сроки задач сдвинуты на "+regularity.Format(shift))
+		if err != nil {
+			log.Error(err, "failed to send vacation end message", "chatID", chatID)
+		}
+		log.Info("vacation ended", "chatID", chatID)
+	}
+}
+
+// RemindTasks sends reminders for all due tasks once
+func (r *remindHanlder) RemindTasks(ctx context.Context) {
+	now := time.Now()
+	log := r.loopLogger(now)
+	r.endVacations(ctx, log, now)
+	r.sendDigests(ctx, log, now)
+	r.sendSuggestions(ctx, log, now)
+	tasks, err := r.DueTasks(ctx, now)
+	if err != nil {
+		log.Error(err, "failed to get due tasks")
+		return
+	}
+	for _, task := range tasks {
+		r.remindTask(ctx, log, task)
+	}
 }
 
 func (r *remindHanlder) Start(ctx context.Context) {
@@ -120,7 +265,7 @@ func (r *remindHanlder) Start(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-time.After(time.Minute):
-			r.remindTasks(ctx)
+			r.RemindTasks(ctx)
 		}
 	}
 }
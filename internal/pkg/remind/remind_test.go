@@ -0,0 +1,120 @@
+package remind
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// fakeTaskStorage serves tasks of chats, other methods are not used by the remind loop
+type fakeTaskStorage struct {
+	entities.TaskStorage
+	tasks map[int64][]entities.UserTask
+}
+
+func (s fakeTaskStorage) GetChatIDs(context.Context) ([]int64, error) {
+	var res []int64
+	for chatID := range s.tasks {
+		res = append(res, chatID)
+	}
+	return res, nil
+}
+
+func (s fakeTaskStorage) GetTasksForChat(_ context.Context, chatID int64) ([]entities.UserTask, error) {
+	return s.tasks[chatID], nil
+}
+
+type fakeTaskUsecase struct {
+	entities.TaskUsecase
+	chats map[int64]entities.Chat
+}
+
+func (u fakeTaskUsecase) GetChat(_ context.Context, chatID int64) (entities.Chat, error) {
+	chat, ok := u.chats[chatID]
+	if !ok {
+		return entities.Chat{}, errors.New("no chat")
+	}
+	return chat, nil
+}
+
+func TestDueTasksBrokenChat(t *testing.T) {
+	now := time.Date(2024, time.November, 8, 16, 0, 0, 0, time.UTC)
+	due := entities.UserTask{ID: 1, ChatID: 1, Regularity: 24 * time.Hour, LastReminded: now.Add(-48 * time.Hour)}
+	r := &remindHanlder{
+		taskRepo: fakeTaskStorage{tasks: map[int64][]entities.UserTask{
+			1: {due},
+			2: {{ID: 2, ChatID: 2, Regularity: 24 * time.Hour, LastReminded: now.Add(-48 * time.Hour)}},
+		}},
+		// settings of chat 2 fail to load
+		taskUsecase: fakeTaskUsecase{chats: map[int64]entities.Chat{1: {ChatID: 1}}},
+	}
+	tasks, err := r.DueTasks(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, []entities.UserTask{due}, tasks)
+}
+
+func TestDueTasksQuietHours(t *testing.T) {
+	// friday
+	day := time.Date(2024, time.November, 8, 0, 0, 0, 0, time.UTC)
+	// due at RemindHour, 15:00 UTC
+	task := entities.UserTask{ID: 1, Name: "Полить цветы", Regularity: 7 * 24 * time.Hour, LastReminded: day.Add(-7 * 24 * time.Hour)}
+	cases := map[string]struct {
+		quiet entities.QuietHours
+		first time.Time
+	}{
+		"no quiet hours": {
+			entities.QuietHours{},
+			day.Add(15*time.Hour + time.Minute),
+		},
+		"evening window": {
+			entities.QuietHours{From: 14 * time.Hour, Until: 16 * time.Hour},
+			day.Add(16 * time.Hour),
+		},
+		// 15:00 UTC is 01:00 in Vladivostok
+		"night far east": {
+			entities.QuietHours{From: 23 * time.Hour, Until: 8 * time.Hour, Offset: 10 * time.Hour},
+			day.Add(22 * time.Hour),
+		},
+		"weekend morning": {
+			entities.QuietHours{From: 23 * time.Hour, Until: 8 * time.Hour, WeekendUntil: 11 * time.Hour, Offset: 10 * time.Hour},
+			day.Add(24*time.Hour + time.Hour),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{t: day.Add(12 * time.Hour)}
+			r := &remindHanlder{
+				taskRepo:    fakeTaskStorage{tasks: map[int64][]entities.UserTask{1: {task}}},
+				taskUsecase: fakeTaskUsecase{chats: map[int64]entities.Chat{1: {ChatID: 1, Quiet: c.quiet}}},
+			}
+			ctx := context.Background()
+			first := time.Time{}
+			for ; clock.Now().Before(day.Add(3 * 24 * time.Hour)); clock.Advance(time.Minute) {
+				due, err := r.DueTasks(ctx, clock.Now())
+				require.NoError(t, err)
+				if len(due) > 0 {
+					first = clock.Now()
+					break
+				}
+			}
+			require.Equal(t, c.first, first)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package remind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/logmw"
+	"house-timer/pkg/regularity"
+
+	"github.com/go-logr/logr"
+	tele "gopkg.in/telebot.v3"
+)
+
+func (r *remindHanlder) suggestionMenu(task entities.UserTask) *tele.ReplyMarkup {
+	menu := &tele.ReplyMarkup{}
+	taskID := strconv.FormatInt(task.ID, 10)
+	menu.Inline(menu.Row(
+		menu.Data("Изменить на "+regularity.Format(task.SuggestedRegularity), r.btnSuggestAccept.Unique, taskID),
+		menu.Data("Оставить", r.btnSuggestKeep.Unique, taskID),
+	))
+	return menu
+}
+
+// sendSuggestions offers regularities found from the history, at the same times reminders are sent
+func (r *remindHanlder) sendSuggestions(ctx context.Context, log logr.Logger, now time.Time) {
+	chats, err := r.taskRepo.GetChatIDs(ctx)
+	if err != nil {
+		log.Error(err, "failed to get chats")
+		return
+	}
+	for _, chatID := range chats {
+		chat, err := r.taskUsecase.GetChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get chat", "chatID", chatID)
+			continue
+		}
+		if chat.OnVacation(now) || chat.Quiet.Quiet(now) {
+			continue
+		}
+		chatTasks, err := r.taskRepo.GetTasksForChat(ctx, chatID)
+		if err != nil {
+			log.Error(err, "failed to get tasks", "chatID", chatID)
+			continue
+		}
+		for _, task := range chatTasks {
+			if !task.SuggestionPending() {
+				continue
+			}
+			message := fmt.Sprintf("Похоже, «%s» вы делаете примерно раз в %s, а я напоминаю %s. Поменять?",
+				task.Name, regularity.Format(task.SuggestedRegularity), regularity.FormatEvery(task.Regularity))
+			_, err = r.bot.Send(&tele.User{ID: chatID}, message, r.suggestionMenu(task))
+			if err != nil {
+				log.Error(err, "failed to send suggestion", "taskID", task.ID)
+				continue
+			}
+			// the offer is sent again next time if it is not marked
+			err = r.taskUsecase.MarkSuggestionSent(ctx, task.ID, now)
+			if err != nil {
+				log.Error(err, "failed to mark suggestion sent", "taskID", task.ID)
+				continue
+			}
+			log.Info("suggested regularity", "taskID", task.ID)
+		}
+	}
+}
+
+func (r *remindHanlder) answerSuggestion(c tele.Context, accept bool) error {
+	chatID := c.Chat().ID
+	log := logmw.GetLogger(c)
+	ctx := logr.NewContext(context.Background(), log)
+
+	taskID, err := strconv.ParseInt(c.Data(), 10, 64)
+	if err != nil {
+		log.Error(err, "bad suggestion data", "data", c.Data())
+		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
+	}
+	task, err := r.taskUsecase.AnswerSuggestion(ctx, chatID, taskID, accept)
+	if errors.Is(err, entities.ErrNoSuggestion) {
+		return c.Edit("Это предложение уже неактуально")
+	}
+	if err != nil {
+		log.Error(err, "failed to answer suggestion")
+		return c.Send("Что-то пошло не так, почитай там логи что ли, лох")
+	}
+	if accept {
+		return c.Edit(fmt.Sprintf("Теперь напоминаю про «%s» %s", task.Name, regularity.FormatEvery(task.Regularity)))
+	}
+	return c.Edit(fmt.Sprintf("Ок, оставляю «%s» %s", task.Name, regularity.FormatEvery(task.Regularity)))
+}
+
+func (r *remindHanlder) handleSuggestAccept(c tele.Context) error {
+	return r.answerSuggestion(c, true)
+}
+
+func (r *remindHanlder) handleSuggestKeep(c tele.Context) error {
+	return r.answerSuggestion(c, false)
+}
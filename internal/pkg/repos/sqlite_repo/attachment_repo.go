@@ -0,0 +1,53 @@
+package sqlite_repo
+
+import (
+	"context"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+func (ts *SqliteTaskStorage) AddAttachment(_ context.Context, attachment entities.Attachment) (int64, error) {
+	result, err := ts.db.Exec("INSERT INTO TaskAttachments(CreatedAt, TaskID, FileID, Kind) VALUES(?, ?, ?, ?)",
+		time.Now().Unix(),
+		attachment.TaskID,
+		attachment.FileID,
+		attachment.Kind)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetAttachments returns attachments of the task in the order they were added
+func (ts *SqliteTaskStorage) GetAttachments(_ context.Context, taskID int64) ([]entities.Attachment, error) {
+	rows, err := ts.db.Query("SELECT ID, TaskID, FileID, Kind FROM TaskAttachments WHERE TaskID = ? AND DeletedAt IS NULL ORDER BY ID ASC", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []entities.Attachment
+	for rows.Next() {
+		var attachment entities.Attachment
+		if err := rows.Scan(&attachment.ID, &attachment.TaskID, &attachment.FileID, &attachment.Kind); err != nil {
+			return nil, err
+		}
+		res = append(res, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (ts *SqliteTaskStorage) DeleteAttachments(_ context.Context, taskID int64) error {
+	_, err := ts.db.Exec("UPDATE TaskAttachments SET DeletedAt = ? WHERE TaskID = ? AND DeletedAt IS NULL", time.Now().Unix(), taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
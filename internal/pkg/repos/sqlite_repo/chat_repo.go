@@ -0,0 +1,123 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+type SqliteChatStorage struct {
+	db *sql.DB
+}
+
+func NewSqliteChatStorage(db *sql.DB) *SqliteChatStorage {
+	return &SqliteChatStorage{
+		db: db,
+	}
+}
+
+var ErrNoChat = errors.New("no chat")
+
+const chatColumns = "ChatID, ICalToken, VacationFrom, VacationUntil, DigestPeriod, DigestHour, DigestWeekday, DigestOnly, DigestSentAt, " +
+	"QuietFrom, QuietUntil, QuietWeekendUntil, UTCOffset"
+
+func scanChat(row *sql.Row) (entities.Chat, error) {
+	var chat entities.Chat
+	var icalToken sql.NullString
+	var vacationFrom, vacationUntil sql.NullInt64
+	var digestSent sql.NullInt64
+	// quiet hours are stored in seconds
+	var quietFrom, quietUntil, quietWeekendUntil, utcOffset int64
+	if err := row.Scan(&chat.ChatID, &icalToken, &vacationFrom, &vacationUntil,
+		&chat.Digest.Period, &chat.Digest.Hour, &chat.Digest.Weekday, &chat.Digest.Only, &digestSent,
+		&quietFrom, &quietUntil, &quietWeekendUntil, &utcOffset); err != nil {
+		return entities.Chat{}, err
+	}
+	chat.ICalToken = icalToken.String
+	if vacationFrom.Valid && vacationUntil.Valid {
+		chat.VacationFrom = time.Unix(vacationFrom.Int64, 0)
+		chat.VacationUntil = time.Unix(vacationUntil.Int64, 0)
+	}
+	if digestSent.Valid {
+		chat.DigestSentAt = time.Unix(digestSent.Int64, 0)
+	}
+	chat.Quiet = entities.QuietHours{
+		From:         time.Duration(quietFrom) * time.Second,
+		Until:        time.Duration(quietUntil) * time.Second,
+		WeekendUntil: time.Duration(quietWeekendUntil) * time.Second,
+		Offset:       time.Duration(utcOffset) * time.Second,
+	}
+	return chat, nil
+}
+
+func (cs *SqliteChatStorage) GetChat(_ context.Context, chatID int64) (entities.Chat, error) {
+	chat, err := scanChat(cs.db.QueryRow("SELECT "+chatColumns+" FROM Chats WHERE ChatID = ? AND DeletedAt IS NULL", chatID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Chat{ChatID: chatID}, nil
+	}
+	return chat, err
+}
+
+func (cs *SqliteChatStorage) GetChatByICalToken(_ context.Context, token string) (entities.Chat, error) {
+	chat, err := scanChat(cs.db.QueryRow("SELECT "+chatColumns+" FROM Chats WHERE ICalToken = ? AND DeletedAt IS NULL", token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Chat{}, ErrNoChat
+	}
+	return chat, err
+}
+
+// ensureChat creates settings row for the chat if there is none
+func (cs *SqliteChatStorage) ensureChat(chatID int64) error {
+	_, err := cs.db.Exec("INSERT INTO Chats(ChatID, CreatedAt) VALUES(?, ?) ON CONFLICT(ChatID) DO NOTHING", chatID, time.Now().Unix())
+	return err
+}
+
+func (cs *SqliteChatStorage) SetICalToken(_ context.Context, chatID int64, token string) error {
+	if err := cs.ensureChat(chatID); err != nil {
+		return err
+	}
+	_, err := cs.db.Exec("UPDATE Chats SET ICalToken = ? WHERE ChatID = ?", token, chatID)
+	return err
+}
+
+func (cs *SqliteChatStorage) SetVacation(_ context.Context, chatID int64, from time.Time, until time.Time) error {
+	if err := cs.ensureChat(chatID); err != nil {
+		return err
+	}
+	_, err := cs.db.Exec("UPDATE Chats SET VacationFrom = ?, VacationUntil = ? WHERE ChatID = ?", from.Unix(), until.Unix(), chatID)
+	return err
+}
+
+func (cs *SqliteChatStorage) ClearVacation(_ context.Context, chatID int64) error {
+	_, err := cs.db.Exec("UPDATE Chats SET VacationFrom = NULL, VacationUntil = NULL WHERE ChatID = ?", chatID)
+	return err
+}
+
+func (cs *SqliteChatStorage) SetDigest(_ context.Context, chatID int64, settings entities.DigestSettings) error {
+	if err := cs.ensureChat(chatID); err != nil {
+		return err
+	}
+	_, err := cs.db.Exec("UPDATE Chats SET DigestPeriod = ?, DigestHour = ?, DigestWeekday = ?, DigestOnly = ? WHERE ChatID = ?",
+		settings.Period, settings.Hour, settings.Weekday, settings.Only, chatID)
+	return err
+}
+
+func (cs *SqliteChatStorage) SetDigestSentAt(_ context.Context, chatID int64, at time.Time) error {
+	if err := cs.ensureChat(chatID); err != nil {
+		return err
+	}
+	_, err := cs.db.Exec("UPDATE Chats SET DigestSentAt = ? WHERE ChatID = ?", at.Unix(), chatID)
+	return err
+}
+
+func (cs *SqliteChatStorage) SetQuietHours(_ context.Context, chatID int64, quiet entities.QuietHours) error {
+	if err := cs.ensureChat(chatID); err != nil {
+		return err
+	}
+	_, err := cs.db.Exec("UPDATE Chats SET QuietFrom = ?, QuietUntil = ?, QuietWeekendUntil = ?, UTCOffset = ? WHERE ChatID = ?",
+		int64(quiet.From.Seconds()), int64(quiet.Until.Seconds()), int64(quiet.WeekendUntil.Seconds()), int64(quiet.Offset.Seconds()), chatID)
+	return err
+}
@@ -0,0 +1,87 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+// SetChecklist replaces the checklist of the task with items in the given order
+func (ts *SqliteTaskStorage) SetChecklist(_ context.Context, taskID int64, items []string) error {
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := setChecklist(tx, taskID, items); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setChecklist rolls tx back on failure
+func setChecklist(tx *sql.Tx, taskID int64, items []string) error {
+	now := time.Now().Unix()
+	_, err := tx.Exec("UPDATE TaskChecklistItems SET DeletedAt = ? WHERE TaskID = ? AND DeletedAt IS NULL", now, taskID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i, text := range items {
+		_, err = tx.Exec("INSERT INTO TaskChecklistItems(CreatedAt, TaskID, Position, Text) VALUES(?, ?, ?, ?)", now, taskID, i, text)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *SqliteTaskStorage) GetChecklist(_ context.Context, taskID int64) ([]entities.ChecklistItem, error) {
+	rows, err := ts.db.Query(
+		`SELECT ID, TaskID, Position, Text, DoneAt
+		FROM TaskChecklistItems
+		WHERE TaskID = ? AND DeletedAt IS NULL
+		ORDER BY Position ASC`,
+		taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []entities.ChecklistItem
+	for rows.Next() {
+		var item entities.ChecklistItem
+		var doneSeconds sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.TaskID, &item.Position, &item.Text, &doneSeconds); err != nil {
+			return nil, err
+		}
+		item.Done = doneSeconds.Valid
+		res = append(res, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (ts *SqliteTaskStorage) SetChecklistItemDone(_ context.Context, itemID int64, done bool) error {
+	var doneAt sql.NullInt64
+	if done {
+		doneAt = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+	}
+	_, err := ts.db.Exec("UPDATE TaskChecklistItems SET DoneAt = ? WHERE ID = ?", doneAt, itemID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetChecklist unticks every item of the task
+func (ts *SqliteTaskStorage) ResetChecklist(_ context.Context, taskID int64) error {
+	_, err := ts.db.Exec("UPDATE TaskChecklistItems SET DoneAt = NULL WHERE TaskID = ? AND DeletedAt IS NULL", taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
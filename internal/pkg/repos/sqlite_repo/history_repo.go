@@ -0,0 +1,142 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+type SqliteHistoryStorage struct {
+	db *sql.DB
+}
+
+func NewSqliteHistoryStorage(db *sql.DB) *SqliteHistoryStorage {
+	return &SqliteHistoryStorage{
+		db: db,
+	}
+}
+
+// execer is either the database or a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (hs *SqliteHistoryStorage) AddRecord(_ context.Context, record entities.HistoryRecord) (int64, error) {
+	return addRecord(hs.db, record)
+}
+
+func addRecord(db execer, record entities.HistoryRecord) (int64, error) {
+	result, err := db.Exec("INSERT INTO TaskHistory(CreatedAt, ChatID, TaskID, Kind, DoneAt, ProofFileID, UserID, UserName, Points) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().Unix(),
+		record.ChatID,
+		record.TaskID,
+		record.Kind,
+		record.DoneAt.Unix(),
+		record.ProofFileID,
+		record.UserID,
+		record.UserName,
+		record.Points)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (hs *SqliteHistoryStorage) GetChatHistory(_ context.Context, chatID int64) ([]entities.HistoryRecord, error) {
+	rows, err := hs.db.Query(
+		`SELECT `+historyColumns+`
+		FROM TaskHistory
+		WHERE ChatID = ? AND DeletedAt IS NULL
+		ORDER BY DoneAt ASC, ID ASC`,
+		chatID)
+	if err != nil {
+		return nil, err
+	}
+	return scanHistory(rows)
+}
+
+func (hs *SqliteHistoryStorage) GetTaskProofs(_ context.Context, taskID int64, limit int) ([]entities.HistoryRecord, error) {
+	rows, err := hs.db.Query(
+		`SELECT `+historyColumns+`
+		FROM TaskHistory
+		WHERE TaskID = ? AND ProofFileID != '' AND DeletedAt IS NULL
+		ORDER BY DoneAt DESC, ID DESC
+		LIMIT ?`,
+		taskID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanHistory(rows)
+}
+
+func (hs *SqliteHistoryStorage) AddSnooze(_ context.Context, snooze entities.Snooze) (int64, error) {
+	result, err := hs.db.Exec("INSERT INTO TaskSnoozes(CreatedAt, ChatID, TaskID, SnoozedAt, UserID, UserName) VALUES(?, ?, ?, ?, ?, ?)",
+		time.Now().Unix(),
+		snooze.ChatID,
+		snooze.TaskID,
+		snooze.SnoozedAt.Unix(),
+		snooze.UserID,
+		snooze.UserName)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (hs *SqliteHistoryStorage) GetChatSnoozes(_ context.Context, chatID int64) ([]entities.Snooze, error) {
+	rows, err := hs.db.Query(
+		`SELECT ID, ChatID, TaskID, SnoozedAt, UserID, UserName
+		FROM TaskSnoozes
+		WHERE ChatID = ?
+		ORDER BY SnoozedAt ASC, ID ASC`,
+		chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []entities.Snooze
+	for rows.Next() {
+		var snooze entities.Snooze
+		var snoozedAtSeconds int64
+		if err := rows.Scan(&snooze.ID, &snooze.ChatID, &snooze.TaskID, &snoozedAtSeconds, &snooze.UserID, &snooze.UserName); err != nil {
+			return nil, err
+		}
+		snooze.SnoozedAt = time.Unix(snoozedAtSeconds, 0)
+		res = append(res, snooze)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+const historyColumns = "ID, ChatID, TaskID, Kind, DoneAt, ProofFileID, UserID, UserName, Points"
+
+func scanHistory(rows *sql.Rows) ([]entities.HistoryRecord, error) {
+	defer rows.Close()
+	var res []entities.HistoryRecord
+	for rows.Next() {
+		var record entities.HistoryRecord
+		var doneAtSeconds int64
+		if err := rows.Scan(&record.ID, &record.ChatID, &record.TaskID, &record.Kind, &doneAtSeconds, &record.ProofFileID,
+			&record.UserID, &record.UserName, &record.Points); err != nil {
+			return nil, err
+		}
+		record.DoneAt = time.Unix(doneAtSeconds, 0)
+		res = append(res, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+func (ts *SqliteTaskStorage) ImportChat(ctx context.Context, batch entities.ImportBatch) error {
+	tx, err := ts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// ids of the batch tasks, dependencies are set once every task has one
+	ids := make([]int64, len(batch.Tasks))
+	for i, imported := range batch.Tasks {
+		update := imported.Update
+		if update.TaskID == 0 {
+			result, err := tx.Exec("INSERT INTO Tasks(ChatID, CreatedAt) VALUES(?, ?)", batch.ChatID, time.Now().Unix())
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			update.TaskID, err = result.LastInsertId()
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := updateTask(tx, update); err != nil {
+			return err
+		}
+		ids[i] = update.TaskID
+		if imported.Checklist != nil {
+			if err := setChecklist(tx, update.TaskID, *imported.Checklist); err != nil {
+				return err
+			}
+		}
+		for _, record := range imported.History {
+			record.ChatID = batch.ChatID
+			record.TaskID = update.TaskID
+			if _, err := addRecord(tx, record); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	for i, imported := range batch.Tasks {
+		if imported.After == nil {
+			continue
+		}
+		afterTaskID := int64(0)
+		if *imported.After >= 0 {
+			afterTaskID = ids[*imported.After]
+		}
+		if err := updateTask(tx, entities.TaskUpdate{TaskID: ids[i], AfterTaskID: &afterTaskID}); err != nil {
+			return err
+		}
+	}
+	if batch.ICalToken != "" || batch.Vacation != nil || batch.Digest != nil || batch.Quiet != nil {
+		_, err := tx.Exec("INSERT INTO Chats(ChatID, CreatedAt) VALUES(?, ?) ON CONFLICT(ChatID) DO NOTHING", batch.ChatID, time.Now().Unix())
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if batch.ICalToken != "" {
+		// the token may be taken if export is imported to another chat of the same instance
+		_, err = tx.Exec(`UPDATE Chats SET ICalToken = ?
+			WHERE ChatID = ? AND (ICalToken IS NULL OR ICalToken = '')
+			AND NOT EXISTS (SELECT 1 FROM Chats WHERE ICalToken = ? AND DeletedAt IS NULL)`,
+			batch.ICalToken, batch.ChatID, batch.ICalToken)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if batch.Vacation != nil {
+		var from, until sql.NullInt64
+		if !batch.Vacation.From.IsZero() {
+			from = sql.NullInt64{Int64: batch.Vacation.From.Unix(), Valid: true}
+			until = sql.NullInt64{Int64: batch.Vacation.Until.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Chats SET VacationFrom = ?, VacationUntil = ? WHERE ChatID = ?", from, until, batch.ChatID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if batch.Digest != nil {
+		// the imported digest is first sent at its next time
+		_, err := tx.Exec("UPDATE Chats SET DigestPeriod = ?, DigestHour = ?, DigestWeekday = ?, DigestOnly = ?, DigestSentAt = ? WHERE ChatID = ?",
+			batch.Digest.Period, batch.Digest.Hour, batch.Digest.Weekday, batch.Digest.Only, time.Now().Unix(), batch.ChatID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if batch.Quiet != nil {
+		_, err := tx.Exec("UPDATE Chats SET QuietFrom = ?, QuietUntil = ?, QuietWeekendUntil = ?, UTCOffset = ? WHERE ChatID = ?",
+			int64(batch.Quiet.From.Seconds()), int64(batch.Quiet.Until.Seconds()), int64(batch.Quiet.WeekendUntil.Seconds()),
+			int64(batch.Quiet.Offset.Seconds()), batch.ChatID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
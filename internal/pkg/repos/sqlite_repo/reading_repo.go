@@ -0,0 +1,51 @@
+package sqlite_repo
+
+import (
+	"context"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+func (ts *SqliteTaskStorage) AddReading(_ context.Context, reading entities.Reading) (int64, error) {
+	result, err := ts.db.Exec("INSERT INTO TaskReadings(CreatedAt, TaskID, Value, LoggedAt) VALUES(?, ?, ?, ?)",
+		time.Now().Unix(),
+		reading.TaskID,
+		reading.Value,
+		reading.LoggedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (ts *SqliteTaskStorage) GetReadings(_ context.Context, taskID int64, since time.Time) ([]entities.Reading, error) {
+	rows, err := ts.db.Query(
+		`SELECT ID, TaskID, Value, LoggedAt
+		FROM TaskReadings
+		WHERE TaskID = ? AND LoggedAt > ? AND DeletedAt IS NULL
+		ORDER BY LoggedAt ASC, ID ASC`,
+		taskID, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []entities.Reading
+	for rows.Next() {
+		var reading entities.Reading
+		var loggedSeconds int64
+		if err := rows.Scan(&reading.ID, &reading.TaskID, &reading.Value, &loggedSeconds); err != nil {
+			return nil, err
+		}
+		reading.LoggedAt = time.Unix(loggedSeconds, 0)
+		res = append(res, reading)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
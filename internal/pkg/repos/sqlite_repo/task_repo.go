@@ -3,9 +3,11 @@ package sqlite_repo
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 
 	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -56,6 +58,14 @@ func (ts *SqliteTaskStorage) CreateTaskRegularity(_ context.Context, taskID int6
 	return nil
 }
 
+func (ts *SqliteTaskStorage) CreateTaskDueDate(_ context.Context, taskID int64, dueAt time.Time) error {
+	_, err := ts.db.Exec("UPDATE Tasks SET OneOff = 1, Regularity = 0, DueAt = ? WHERE ID = ?", dueAt.Unix(), taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (ts *SqliteTaskStorage) FinishCreation(_ context.Context, taskID int64) error {
 	result, err := ts.db.Exec("UPDATE Tasks SET CreatedAt = ? WHERE ID = ?", time.Now().Unix(), taskID)
 	if err != nil {
@@ -68,24 +78,88 @@ func (ts *SqliteTaskStorage) FinishCreation(_ context.Context, taskID int64) err
 	return nil
 }
 
-func (ts *SqliteTaskStorage) GetTasksForChat(_ context.Context, chatID int64) ([]entities.UserTask, error) {
-	rows, err := ts.db.Query("SELECT ID, Name, Regularity, RemindedAt, ChatID, RemindAfter  FROM Tasks WHERE ChatID = ? AND CreatedAt IS NOT NULL AND DeletedAt IS NULL ORDER BY CreatedAt ASC", chatID)
+const taskColumns = "ID, Name, Regularity, RemindedAt, ChatID, RemindAfter, DeletedAt, PausedAt, Anchor, AnchorAt, OneOff, DueAt, ArchivedAt, Notes, RequiresProof, Category, Tags, AfterTaskID, AfterDelay, ChainedAt, Threshold, CounterUnit, Counter, CounterReachedAt, Season, SuggestedRegularity, SuggestedAt, Points"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads taskColumns, name and regularity are empty for not finished tasks
+func scanTask(row scanner) (entities.UserTask, error) {
+	var task entities.UserTask
+	var name sql.NullString
+	var regularitySeconds sql.NullInt64
+	var remindedSeconds int64
+	var remindAfterSeconds int64
+	var deletedSeconds sql.NullInt64
+	var pausedSeconds sql.NullInt64
+	var anchorSeconds sql.NullInt64
+	var dueSeconds sql.NullInt64
+	var archivedSeconds sql.NullInt64
+	// tags are stored space separated
+	var tags string
+	var afterTaskID sql.NullInt64
+	var afterDelaySeconds int64
+	var chainedSeconds sql.NullInt64
+	var reachedSeconds sql.NullInt64
+	var season string
+	var suggestedSeconds int64
+	var suggestedAtSeconds sql.NullInt64
+	if err := row.Scan(&task.ID, &name, &regularitySeconds, &remindedSeconds, &task.ChatID, &remindAfterSeconds,
+		&deletedSeconds, &pausedSeconds, &task.Anchor, &anchorSeconds, &task.OneOff, &dueSeconds, &archivedSeconds,
+		&task.Notes, &task.RequiresProof, &task.Category, &tags, &afterTaskID, &afterDelaySeconds, &chainedSeconds,
+		&task.Threshold, &task.CounterUnit, &task.Counter, &reachedSeconds, &season,
+		&suggestedSeconds, &suggestedAtSeconds, &task.Points); err != nil {
+		return entities.UserTask{}, err
+	}
+	windows, err := regularity.ParseWindows(season)
 	if err != nil {
-		return nil, err
+		return entities.UserTask{}, err
+	}
+	task.Season = windows
+	task.Name = name.String
+	task.Regularity = time.Duration(regularitySeconds.Int64) * time.Second
+	task.LastReminded = time.Unix(remindedSeconds, 0)
+	task.RemindAfter = time.Duration(remindAfterSeconds) * time.Second
+	if deletedSeconds.Valid {
+		task.DeletedAt = time.Unix(deletedSeconds.Int64, 0)
 	}
+	if pausedSeconds.Valid {
+		task.PausedAt = time.Unix(pausedSeconds.Int64, 0)
+	}
+	if anchorSeconds.Valid {
+		task.StartAt = time.Unix(anchorSeconds.Int64, 0)
+	}
+	if dueSeconds.Valid {
+		task.DueAt = time.Unix(dueSeconds.Int64, 0)
+	}
+	if archivedSeconds.Valid {
+		task.ArchivedAt = time.Unix(archivedSeconds.Int64, 0)
+	}
+	task.Tags = strings.Fields(tags)
+	task.AfterTaskID = afterTaskID.Int64
+	task.AfterDelay = time.Duration(afterDelaySeconds) * time.Second
+	if chainedSeconds.Valid {
+		task.ChainedAt = time.Unix(chainedSeconds.Int64, 0)
+	}
+	if reachedSeconds.Valid {
+		task.CounterReachedAt = time.Unix(reachedSeconds.Int64, 0)
+	}
+	task.SuggestedRegularity = time.Duration(suggestedSeconds) * time.Second
+	if suggestedAtSeconds.Valid {
+		task.SuggestedAt = time.Unix(suggestedAtSeconds.Int64, 0)
+	}
+	return task, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]entities.UserTask, error) {
 	defer rows.Close()
 	var res []entities.UserTask
 	for rows.Next() {
-		var task entities.UserTask
-		var regularitySeconds uint64
-		var remindedSeconds int64
-		var remindAfterSeconds int64
-		if err := rows.Scan(&task.ID, &task.Name, &regularitySeconds, &remindedSeconds, &task.ChatID, &remindAfterSeconds); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
-		task.Regularity = time.Duration(regularitySeconds) * time.Second
-		task.LastReminded = time.Unix(remindedSeconds, 0)
-		task.RemindAfter = time.Duration(remindAfterSeconds) * time.Second
 		res = append(res, task)
 	}
 	if err := rows.Err(); err != nil {
@@ -94,11 +168,32 @@ func (ts *SqliteTaskStorage) GetTasksForChat(_ context.Context, chatID int64) ([
 	return res, nil
 }
 
+// GetTask returns task by id, including not finished and deleted ones
+func (ts *SqliteTaskStorage) GetTask(_ context.Context, taskID int64) (entities.UserTask, error) {
+	return scanTask(ts.db.QueryRow("SELECT "+taskColumns+" FROM Tasks WHERE ID = ?", taskID))
+}
+
+func (ts *SqliteTaskStorage) GetTasksForChat(_ context.Context, chatID int64) ([]entities.UserTask, error) {
+	rows, err := ts.db.Query("SELECT "+taskColumns+" FROM Tasks WHERE ChatID = ? AND CreatedAt IS NOT NULL AND DeletedAt IS NULL AND ArchivedAt IS NULL ORDER BY CreatedAt ASC", chatID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
 func (ts *SqliteTaskStorage) UpdateTask(ctx context.Context, update entities.TaskUpdate) error {
 	tx, err := ts.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	if err := updateTask(tx, update); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateTask rolls tx back on failure
+func updateTask(tx *sql.Tx, update entities.TaskUpdate) error {
 	if update.Name != nil {
 		_, err := tx.Exec("UPDATE Tasks SET Name = ? WHERE ID = ?", *update.Name, update.TaskID)
 		if err != nil {
@@ -127,7 +222,168 @@ func (ts *SqliteTaskStorage) UpdateTask(ctx context.Context, update entities.Tas
 			return err
 		}
 	}
-	return tx.Commit()
+	if update.Anchor != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Anchor = ? WHERE ID = ?", *update.Anchor, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.DueAt != nil {
+		var dueAt sql.NullInt64
+		if !update.DueAt.IsZero() {
+			dueAt = sql.NullInt64{Int64: update.DueAt.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Tasks SET DueAt = ? WHERE ID = ?", dueAt, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Notes != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Notes = ? WHERE ID = ?", *update.Notes, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.AfterTaskID != nil {
+		afterTaskID := sql.NullInt64{Int64: *update.AfterTaskID, Valid: *update.AfterTaskID != 0}
+		_, err := tx.Exec("UPDATE Tasks SET AfterTaskID = ? WHERE ID = ?", afterTaskID, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.AfterDelay != nil {
+		_, err := tx.Exec("UPDATE Tasks SET AfterDelay = ? WHERE ID = ?", int64(update.AfterDelay.Seconds()), update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.ChainedAt != nil {
+		chainedAt := sql.NullInt64{}
+		if !update.ChainedAt.IsZero() {
+			chainedAt = sql.NullInt64{Int64: update.ChainedAt.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Tasks SET ChainedAt = ? WHERE ID = ?", chainedAt, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Threshold != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Threshold = ? WHERE ID = ?", *update.Threshold, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.CounterUnit != nil {
+		_, err := tx.Exec("UPDATE Tasks SET CounterUnit = ? WHERE ID = ?", *update.CounterUnit, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Counter != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Counter = ? WHERE ID = ?", *update.Counter, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.CounterReachedAt != nil {
+		reachedAt := sql.NullInt64{}
+		if !update.CounterReachedAt.IsZero() {
+			reachedAt = sql.NullInt64{Int64: update.CounterReachedAt.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Tasks SET CounterReachedAt = ? WHERE ID = ?", reachedAt, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.SuggestedRegularity != nil {
+		_, err := tx.Exec("UPDATE Tasks SET SuggestedRegularity = ? WHERE ID = ?", int64(update.SuggestedRegularity.Seconds()), update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.SuggestedAt != nil {
+		var suggestedAt sql.NullInt64
+		if !update.SuggestedAt.IsZero() {
+			suggestedAt = sql.NullInt64{Int64: update.SuggestedAt.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Tasks SET SuggestedAt = ? WHERE ID = ?", suggestedAt, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Points != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Points = ? WHERE ID = ?", *update.Points, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Season != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Season = ? WHERE ID = ?", regularity.FormatWindows(*update.Season), update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Category != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Category = ? WHERE ID = ?", *update.Category, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.Tags != nil {
+		_, err := tx.Exec("UPDATE Tasks SET Tags = ? WHERE ID = ?", strings.Join(*update.Tags, " "), update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.RequiresProof != nil {
+		_, err := tx.Exec("UPDATE Tasks SET RequiresProof = ? WHERE ID = ?", *update.RequiresProof, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.OneOff != nil {
+		_, err := tx.Exec("UPDATE Tasks SET OneOff = ? WHERE ID = ?", *update.OneOff, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.StartAt != nil {
+		_, err := tx.Exec("UPDATE Tasks SET AnchorAt = ? WHERE ID = ?", update.StartAt.Unix(), update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if update.PausedAt != nil {
+		var pausedAt sql.NullInt64
+		if !update.PausedAt.IsZero() {
+			pausedAt = sql.NullInt64{Int64: update.PausedAt.Unix(), Valid: true}
+		}
+		_, err := tx.Exec("UPDATE Tasks SET PausedAt = ? WHERE ID = ?", pausedAt, update.TaskID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
 }
 
 func (ts *SqliteTaskStorage) GetChatIDs(_ context.Context) ([]int64, error) {
@@ -158,3 +414,94 @@ func (ts *SqliteTaskStorage) DeleteTask(_ context.Context, taskID int64) error {
 	}
 	return nil
 }
+
+// GetDeletedTasksForChat returns tasks in trash, recently deleted first
+func (ts *SqliteTaskStorage) GetDeletedTasksForChat(_ context.Context, chatID int64) ([]entities.UserTask, error) {
+	rows, err := ts.db.Query("SELECT "+taskColumns+" FROM Tasks WHERE ChatID = ? AND CreatedAt IS NOT NULL AND DeletedAt IS NOT NULL ORDER BY DeletedAt DESC", chatID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+func (ts *SqliteTaskStorage) RestoreTask(_ context.Context, taskID int64) error {
+	_, err := ts.db.Exec("UPDATE Tasks SET DeletedAt = NULL WHERE ID = ?", taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ts *SqliteTaskStorage) PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := ts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM TaskHistory WHERE TaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM TaskSnoozes WHERE TaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM TaskAttachments WHERE TaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM TaskChecklistItems WHERE TaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM TaskReadings WHERE TaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	// tasks following the purged ones become independent
+	_, err = tx.Exec("UPDATE Tasks SET AfterTaskID = NULL, AfterDelay = 0, ChainedAt = NULL WHERE AfterTaskID IN (SELECT ID FROM Tasks WHERE DeletedAt < ?)", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	result, err := tx.Exec("DELETE FROM Tasks WHERE DeletedAt < ?", before.Unix())
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return purged, tx.Commit()
+}
+
+func (ts *SqliteTaskStorage) PauseTask(_ context.Context, taskID int64, at time.Time) error {
+	_, err := ts.db.Exec("UPDATE Tasks SET PausedAt = ? WHERE ID = ?", at.Unix(), taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ts *SqliteTaskStorage) ResumeTask(_ context.Context, taskID int64) error {
+	_, err := ts.db.Exec("UPDATE Tasks SET PausedAt = NULL WHERE ID = ?", taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ArchiveTask hides a finished one-off task from the task list, its history is kept
+func (ts *SqliteTaskStorage) ArchiveTask(_ context.Context, taskID int64) error {
+	_, err := ts.db.Exec("UPDATE Tasks SET ArchivedAt = ? WHERE ID = ?", time.Now().Unix(), taskID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -55,7 +55,7 @@ var ErrNoTaskEvent = errors.New("ErrNoTasks")
 
 func (ts *SqliteTaskEventStorage) GetCurrentTaskEvent(_ context.Context, chatID int64) (entities.UserTaskEvent, error) {
 	rows, err := ts.db.Query(
-		`SELECT ID, Type, Step, TaskID, ChatID 
+		`SELECT ID, Type, Step, TaskID, ChatID, Payload
 		FROM TaskEvents 
 		WHERE ChatID = ? AND DeletedAt IS NULL AND CreatedAt IS NOT NULL`,
 		chatID)
@@ -67,7 +67,7 @@ func (ts *SqliteTaskEventStorage) GetCurrentTaskEvent(_ context.Context, chatID
 	for rows.Next() {
 		var task entities.UserTaskEvent
 		var taskID sql.NullInt64
-		if err := rows.Scan(&task.ID, &task.Type, &task.Step, &taskID, &task.ChatID); err != nil {
+		if err := rows.Scan(&task.ID, &task.Type, &task.Step, &taskID, &task.ChatID, &task.Payload); err != nil {
 			return entities.UserTaskEvent{}, err
 		}
 		if taskID.Valid {
@@ -123,3 +123,11 @@ func (ts *SqliteTaskEventStorage) DeleteEvent(ctx context.Context, eventID int64
 	}
 	return tx.Commit()
 }
+
+func (ts *SqliteTaskEventStorage) SetPayload(_ context.Context, eventID int64, payload string) error {
+	_, err := ts.db.Exec("UPDATE TaskEvents SET Payload = ? WHERE ID = ?", payload, eventID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
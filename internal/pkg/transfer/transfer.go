@@ -0,0 +1,299 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+// Version of the export format
+const Version = 1
+
+const maxNameLength = 256
+
+var ErrBadFormat = errors.New("bad export format")
+
+var ErrUnsupportedVersion = errors.New("unsupported export version")
+
+var ErrInvalidExport = errors.New("invalid export")
+
+var anchorModes = map[entities.AnchorMode]bool{
+	entities.AnchorCompletion: true,
+	entities.AnchorFixed:      true,
+}
+
+var digestPeriods = map[entities.DigestPeriod]bool{
+	entities.DigestOff:    true,
+	entities.DigestDaily:  true,
+	entities.DigestWeekly: true,
+}
+
+var historyKinds = map[entities.HistoryKind]bool{
+	entities.HistoryCompleted: true,
+	entities.HistorySkipped:   true,
+}
+
+var csvHeader = []string{"name", "regularity", "last_reminded", "remind_after", "due"}
+
+// legacyCSVHeader is the header of exports made before one-off tasks
+var legacyCSVHeader = csvHeader[:4]
+
+func EncodeJSON(export entities.ChatExport) ([]byte, error) {
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// EncodeCSV writes tasks only, history and settings are in JSON export
+func EncodeCSV(export entities.ChatExport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, task := range export.Tasks {
+		remindAfter := ""
+		if task.RemindAfterSeconds > 0 {
+			remindAfter = regularity.Format(time.Duration(task.RemindAfterSeconds) * time.Second)
+		}
+		// one-off tasks have empty regularity
+		every := ""
+		if !task.OneOff {
+			every = regularity.Format(time.Duration(task.RegularitySeconds) * time.Second)
+		}
+		due := ""
+		if task.DueAt != nil {
+			due = task.DueAt.Format(time.RFC3339)
+		}
+		err := w.Write([]string{
+			task.Name,
+			every,
+			task.LastReminded.Format(time.RFC3339),
+			remindAfter,
+			due,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func decodeJSON(r io.Reader) (entities.ChatExport, error) {
+	var export entities.ChatExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return entities.ChatExport{}, errors.Join(ErrBadFormat, err)
+	}
+	return export, nil
+}
+
+func parseCSVDuration(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := regularity.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(d.Seconds()), nil
+}
+
+func decodeCSV(r io.Reader) (entities.ChatExport, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return entities.ChatExport{}, errors.Join(ErrBadFormat, err)
+	}
+	if len(records) == 0 || (strings.Join(records[0], ",") != strings.Join(csvHeader, ",") &&
+		strings.Join(records[0], ",") != strings.Join(legacyCSVHeader, ",")) {
+		return entities.ChatExport{}, errors.Join(ErrBadFormat, errors.New("bad csv header"))
+	}
+	export := entities.ChatExport{Version: Version}
+	for i, record := range records[1:] {
+		line := i + 2
+		task := entities.ExportedTask{ID: int64(i + 1), Name: record[0]}
+		if task.RegularitySeconds, err = parseCSVDuration(record[1]); err != nil {
+			return entities.ChatExport{}, errors.Join(ErrBadFormat, fmt.Errorf("line %d: %w", line, err))
+		}
+		if record[2] != "" {
+			if task.LastReminded, err = time.Parse(time.RFC3339, record[2]); err != nil {
+				return entities.ChatExport{}, errors.Join(ErrBadFormat, fmt.Errorf("line %d: %w", line, err))
+			}
+		}
+		if task.RemindAfterSeconds, err = parseCSVDuration(record[3]); err != nil {
+			return entities.ChatExport{}, errors.Join(ErrBadFormat, fmt.Errorf("line %d: %w", line, err))
+		}
+		if len(record) > 4 && record[4] != "" {
+			dueAt, err := time.Parse(time.RFC3339, record[4])
+			if err != nil {
+				return entities.ChatExport{}, errors.Join(ErrBadFormat, fmt.Errorf("line %d: %w", line, err))
+			}
+			task.DueAt = &dueAt
+			task.OneOff = record[1] == ""
+		}
+		export.Tasks = append(export.Tasks, task)
+	}
+	return export, nil
+}
+
+// Decode reads JSON or CSV export depending on the file extension
+func Decode(fileName string, r io.Reader) (entities.ChatExport, error) {
+	if strings.EqualFold(path.Ext(fileName), ".csv") {
+		return decodeCSV(r)
+	}
+	return decodeJSON(r)
+}
+
+func invalid(format string, args ...any) error {
+	return errors.Join(ErrInvalidExport, fmt.Errorf(format, args...))
+}
+
+func Validate(export entities.ChatExport) error {
+	if export.Version != Version {
+		return errors.Join(ErrUnsupportedVersion, fmt.Errorf("version %d", export.Version))
+	}
+	ids := map[int64]bool{}
+	names := map[string]bool{}
+	for _, task := range export.Tasks {
+		name := entities.NormalizeName(task.Name)
+		if name == "" || len([]rune(task.Name)) > maxNameLength {
+			return invalid("task %d: bad name", task.ID)
+		}
+		if names[name] {
+			return invalid("task %d: duplicate name %q", task.ID, task.Name)
+		}
+		if ids[task.ID] {
+			return invalid("task %d: duplicate id", task.ID)
+		}
+		if task.OneOff && (task.DueAt == nil || task.RegularitySeconds != 0) {
+			return invalid("task %d: one-off task must have due date and no regularity", task.ID)
+		}
+		if !task.OneOff && task.RegularitySeconds <= 0 {
+			return invalid("task %d: regularity must be > 0", task.ID)
+		}
+		if task.RemindAfterSeconds < 0 {
+			return invalid("task %d: remind after must be >= 0", task.ID)
+		}
+		if task.Season != nil {
+			if _, err := regularity.ParseWindows(*task.Season); err != nil {
+				return invalid("task %d: bad season %q", task.ID, *task.Season)
+			}
+		}
+		if task.Points != nil && (*task.Points < 0 || *task.Points > entities.MaxTaskPoints) {
+			return invalid("task %d: points must be from 0 to %d", task.ID, entities.MaxTaskPoints)
+		}
+		if task.Threshold != nil && (*task.Threshold < 0 || task.Counter < 0) {
+			return invalid("task %d: threshold and counter must be >= 0", task.ID)
+		}
+		if task.Tags != nil {
+			for _, tag := range *task.Tags {
+				if tag == "" || entities.NormalizeLabel(tag) != tag || len(strings.Fields(tag)) != 1 {
+					return invalid("task %d: bad tag %q", task.ID, tag)
+				}
+			}
+		}
+		if task.Anchor != nil && (!anchorModes[*task.Anchor] ||
+			(*task.Anchor == entities.AnchorFixed && (task.OneOff || task.AnchorAt == nil || task.AnchorAt.IsZero()))) {
+			return invalid("task %d: bad anchor %q", task.ID, *task.Anchor)
+		}
+		if task.Checklist != nil {
+			for _, item := range *task.Checklist {
+				if strings.TrimSpace(item) != item || item == "" || strings.Contains(item, "\n") || len([]rune(item)) > maxNameLength {
+					return invalid("task %d: bad checklist item %q", task.ID, item)
+				}
+			}
+		}
+		if task.AfterDelaySeconds < 0 {
+			return invalid("task %d: after delay must be >= 0", task.ID)
+		}
+		names[name] = true
+		ids[task.ID] = true
+	}
+	if err := validateDependencies(export.Tasks, names); err != nil {
+		return err
+	}
+	for _, record := range export.History {
+		if !ids[record.TaskID] {
+			return invalid("history record of unknown task %d", record.TaskID)
+		}
+		if !historyKinds[record.Kind] || record.DoneAt.IsZero() {
+			return invalid("history record of task %d: bad kind or no time", record.TaskID)
+		}
+		if record.Points < 0 {
+			return invalid("history record of task %d: points must be >= 0", record.TaskID)
+		}
+	}
+	if !validICalToken(export.Settings.ICalToken) {
+		return invalid("bad ical token")
+	}
+	return validateSettings(export.Settings)
+}
+
+// validateDependencies checks that tasks follow other tasks of the export without cycles
+func validateDependencies(tasks []entities.ExportedTask, names map[string]bool) error {
+	after := map[string]string{}
+	for _, task := range tasks {
+		if task.After == nil || *task.After == "" {
+			continue
+		}
+		name, followed := entities.NormalizeName(task.Name), entities.NormalizeName(*task.After)
+		if !names[followed] {
+			return invalid("task %d: follows unknown task %q", task.ID, *task.After)
+		}
+		after[name] = followed
+	}
+	for _, task := range tasks {
+		start := entities.NormalizeName(task.Name)
+		seen := map[string]bool{}
+		for name := after[start]; name != ""; name = after[name] {
+			if name == start {
+				return invalid("task %d: dependencies make a cycle", task.ID)
+			}
+			if seen[name] {
+				break
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+func validClock(seconds int64) bool {
+	return seconds >= 0 && time.Duration(seconds)*time.Second <= 24*time.Hour
+}
+
+func validateSettings(settings entities.ExportedSettings) error {
+	if vacation := settings.Vacation; vacation != nil &&
+		(vacation.From.IsZero() != vacation.Until.IsZero() || vacation.Until.Before(vacation.From)) {
+		return invalid("bad vacation")
+	}
+	if digest := settings.Digest; digest != nil && (!digestPeriods[digest.Period] ||
+		digest.Hour < 0 || digest.Hour > 23 || digest.Weekday < time.Sunday || digest.Weekday > time.Saturday) {
+		return invalid("bad digest settings")
+	}
+	if quiet := settings.Quiet; quiet != nil {
+		offset := time.Duration(quiet.OffsetSeconds) * time.Second
+		if !validClock(quiet.FromSeconds) || !validClock(quiet.UntilSeconds) || !validClock(quiet.WeekendUntilSeconds) ||
+			offset > entities.MaxUTCOffset || offset < -entities.MaxUTCOffset {
+			return invalid("bad quiet hours")
+		}
+	}
+	return nil
+}
+
+// validICalToken accepts no token or the hex encoded one
+func validICalToken(token string) bool {
+	if token == "" {
+		return true
+	}
+	decoded, err := hex.DecodeString(token)
+	return err == nil && len(decoded) == entities.ICalTokenBytes
+}
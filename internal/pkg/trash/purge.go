@@ -0,0 +1,52 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+
+	"github.com/go-logr/logr"
+)
+
+const purgeInterval = time.Hour
+
+type purgeHandler struct {
+	taskUsecase entities.TaskUsecase
+	retention   time.Duration
+	logger      logr.Logger
+}
+
+// NewPurgeHandler removes tasks that are in trash longer than retention
+func NewPurgeHandler(taskUsecase entities.TaskUsecase, retention time.Duration) *purgeHandler {
+	return &purgeHandler{
+		taskUsecase: taskUsecase,
+		retention:   retention,
+		logger:      logr.FromSlogHandler(slog.NewTextHandler(log.Writer(), nil)).WithName("purge"),
+	}
+}
+
+func (p *purgeHandler) purge(ctx context.Context) {
+	purged, err := p.taskUsecase.PurgeTrash(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		p.logger.Error(err, "failed to purge trash")
+		return
+	}
+	if purged > 0 {
+		p.logger.Info("purged trash", "tasks", purged)
+	}
+}
+
+func (p *purgeHandler) Start(ctx context.Context) {
+	p.purge(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(purgeInterval):
+			p.purge(ctx)
+		}
+	}
+}
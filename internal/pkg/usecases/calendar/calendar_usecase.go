@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/pkg/ical"
+	"house-timer/pkg/regularity"
+)
+
+const (
+	calendarName  = "Домашние дела"
+	eventDuration = 30 * time.Minute
+)
+
+type CalendarUsecase struct {
+	ts entities.TaskStorage
+	cs entities.ChatStorage
+
+	feedBaseURL string
+}
+
+// NewCalendarUsecase creates usecase, the subscribable feed is disabled when feedBaseURL is empty
+func NewCalendarUsecase(
+	taskStorage entities.TaskStorage,
+	chatStorage entities.ChatStorage,
+	feedBaseURL string,
+) *CalendarUsecase {
+	return &CalendarUsecase{
+		ts:          taskStorage,
+		cs:          chatStorage,
+		feedBaseURL: strings.TrimSuffix(feedBaseURL, "/"),
+	}
+}
+
+func taskEvent(task entities.UserTask) ical.Event {
+	if task.OneOff {
+		return ical.Event{
+			UID:         fmt.Sprintf("task-%d@house-timer", task.ID),
+			Summary:     task.Name,
+			Description: "Разовое напоминание",
+			Start:       task.NextRemind(),
+			Duration:    eventDuration,
+		}
+	}
+	return ical.Event{
+		UID:         fmt.Sprintf("task-%d@house-timer", task.ID),
+		Summary:     task.Name,
+		Description: "Напоминание " + regularity.FormatEvery(task.Regularity),
+		Start:       task.NextRemind(),
+		Duration:    eventDuration,
+		Every:       task.Regularity,
+	}
+}
+
+func (cu *CalendarUsecase) ExportCalendar(ctx context.Context, chatID int64) ([]byte, error) {
+	tasks, err := cu.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return nil, errors.Join(ErrGetTasks, err)
+	}
+	cal := ical.Calendar{Name: calendarName}
+	for _, task := range tasks {
+		// paused tasks have no due date
+		if task.Paused() {
+			continue
+		}
+		cal.Events = append(cal.Events, taskEvent(task))
+	}
+	return cal.Marshal(time.Now()), nil
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, entities.ICalTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// FeedURL returns the secret feed url of the chat creating it on first call
+func (cu *CalendarUsecase) FeedURL(ctx context.Context, chatID int64) (string, error) {
+	if cu.feedBaseURL == "" {
+		return "", nil
+	}
+	chat, err := cu.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return "", errors.Join(ErrGetChat, err)
+	}
+	if chat.ICalToken == "" {
+		chat.ICalToken, err = newToken()
+		if err != nil {
+			return "", errors.Join(ErrCreateFeedToken, err)
+		}
+		err = cu.cs.SetICalToken(ctx, chatID, chat.ICalToken)
+		if err != nil {
+			return "", errors.Join(ErrCreateFeedToken, err)
+		}
+	}
+	return fmt.Sprintf("%s/ical/%s.ics", cu.feedBaseURL, chat.ICalToken), nil
+}
+
+func (cu *CalendarUsecase) ExportFeed(ctx context.Context, token string) ([]byte, error) {
+	chat, err := cu.cs.GetChatByICalToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, sqlite_repo.ErrNoChat) {
+			return nil, ErrNoFeed
+		}
+		return nil, errors.Join(ErrGetChat, err)
+	}
+	return cu.ExportCalendar(ctx, chat.ChatID)
+}
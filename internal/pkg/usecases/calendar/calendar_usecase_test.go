@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTaskStorage serves tasks of chats, other methods are not used by the calendar
+type fakeTaskStorage struct {
+	entities.TaskStorage
+	tasks map[int64][]entities.UserTask
+}
+
+func (s fakeTaskStorage) GetTasksForChat(_ context.Context, chatID int64) ([]entities.UserTask, error) {
+	return s.tasks[chatID], nil
+}
+
+type fakeChatStorage struct {
+	entities.ChatStorage
+	chats map[int64]entities.Chat
+}
+
+func (s fakeChatStorage) GetChat(_ context.Context, chatID int64) (entities.Chat, error) {
+	return s.chats[chatID], nil
+}
+
+func (s fakeChatStorage) GetChatByICalToken(_ context.Context, token string) (entities.Chat, error) {
+	for _, chat := range s.chats {
+		if chat.ICalToken == token {
+			return chat, nil
+		}
+	}
+	return entities.Chat{}, sqlite_repo.ErrNoChat
+}
+
+func (s fakeChatStorage) SetICalToken(_ context.Context, chatID int64, token string) error {
+	chat := s.chats[chatID]
+	chat.ChatID, chat.ICalToken = chatID, token
+	s.chats[chatID] = chat
+	return nil
+}
+
+func newTestUsecase(feedBaseURL string) *CalendarUsecase {
+	day := 24 * time.Hour
+	lastReminded := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	tasks := fakeTaskStorage{tasks: map[int64][]entities.UserTask{
+		1: {
+			{ID: 1, Name: "Полить цветы", Regularity: 3 * day, LastReminded: lastReminded},
+			{ID: 2, Name: "Продлить страховку", OneOff: true, DueAt: lastReminded.Add(10 * day)},
+			{ID: 3, Name: "Почистить бассейн", Regularity: 7 * day, LastReminded: lastReminded, PausedAt: lastReminded},
+		},
+	}}
+	chats := fakeChatStorage{chats: map[int64]entities.Chat{1: {ChatID: 1}}}
+	return NewCalendarUsecase(tasks, chats, feedBaseURL)
+}
+
+func TestExportCalendar(t *testing.T) {
+	cu := newTestUsecase("")
+	data, err := cu.ExportCalendar(context.Background(), 1)
+	require.NoError(t, err)
+	cal := string(data)
+	// the paused task is left out
+	require.Equal(t, 2, strings.Count(cal, "BEGIN:VEVENT"))
+	require.Contains(t, cal, "SUMMARY:Полить цветы")
+	require.Contains(t, cal, "RRULE:FREQ=DAILY;INTERVAL=3")
+	require.Contains(t, cal, "SUMMARY:Продлить страховку")
+	require.Equal(t, 1, strings.Count(cal, "RRULE"))
+	require.NotContains(t, cal, "Почистить бассейн")
+}
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+
+	url, err := newTestUsecase("").FeedURL(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, url)
+
+	cu := newTestUsecase("https://example.com/")
+	url, err = cu.FeedURL(ctx, 1)
+	require.NoError(t, err)
+	token, ok := strings.CutPrefix(url, "https://example.com/ical/")
+	require.True(t, ok)
+	token, ok = strings.CutSuffix(token, ".ics")
+	require.True(t, ok)
+	require.Len(t, token, 2*entities.ICalTokenBytes)
+
+	again, err := cu.FeedURL(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, url, again)
+
+	feed, err := cu.ExportFeed(ctx, token)
+	require.NoError(t, err)
+	require.Contains(t, string(feed), "SUMMARY:Полить цветы")
+
+	_, err = cu.ExportFeed(ctx, "unknown")
+	require.ErrorIs(t, err, ErrNoFeed)
+}
@@ -0,0 +1,13 @@
+package calendar
+
+import (
+	"errors"
+)
+
+var ErrGetTasks = errors.New("failed to get tasks")
+
+var ErrGetChat = errors.New("failed to get chat")
+
+var ErrNoFeed = errors.New("no feed for token")
+
+var ErrCreateFeedToken = errors.New("failed to create feed token")
@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+)
+
+var noCategoryAnswers = map[string]bool{
+	"-":             true,
+	"нет":           true,
+	"без категории": true,
+	"пропустить":    true,
+}
+
+// parseCategory splits "Кухня #дети #еженедельно" into the category and tags,
+// tags are normalized and deduplicated
+func parseCategory(message string) (string, []string) {
+	var words []string
+	tags := []string{}
+	for _, word := range strings.Fields(message) {
+		if !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+			continue
+		}
+		tag := entities.NormalizeLabel(word)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	category := strings.Join(words, " ")
+	if noCategoryAnswers[answer(category)] {
+		category = ""
+	}
+	return category, tags
+}
+
+// setCategory sets the category and tags of the task from the message,
+// the category is spelled the same as the one already used in the chat
+func (t *TaskUsecase) setCategory(ctx context.Context, taskID int64, message string) error {
+	category, tags := parseCategory(message)
+	if category != "" {
+		task, err := t.ts.GetTask(ctx, taskID)
+		if err != nil {
+			return errors.Join(ErrGetTasks, err)
+		}
+		categories, err := t.GetCategories(ctx, task.ChatID)
+		if err != nil {
+			return err
+		}
+		for _, known := range categories {
+			if entities.NormalizeLabel(known) == entities.NormalizeLabel(category) {
+				category = known
+				break
+			}
+		}
+	}
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:   taskID,
+		Category: &category,
+		Tags:     &tags,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	return nil
+}
+
+// GetCategories returns categories used in the chat followed by the default ones
+func (t *TaskUsecase) GetCategories(ctx context.Context, chatID int64) ([]string, error) {
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return nil, errors.Join(ErrGetTasks, err)
+	}
+	var res []string
+	seen := map[string]bool{}
+	add := func(category string) {
+		if category == "" || seen[entities.NormalizeLabel(category)] {
+			return
+		}
+		seen[entities.NormalizeLabel(category)] = true
+		res = append(res, category)
+	}
+	for _, task := range tasks {
+		add(task.Category)
+	}
+	for _, category := range entities.DefaultCategories {
+		add(category)
+	}
+	return res, nil
+}
+
+// StartTaskCategoryChoice asks the optional category during creation instead of the kind
+func (t *TaskUsecase) StartTaskCategoryChoice(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationWaitKind {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitCategory)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// ChooseTaskCategory sets the category picked from the menu during creation, empty category skips it
+func (t *TaskUsecase) ChooseTaskCategory(ctx context.Context, chatID int64, category string) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationWaitCategory {
+		return ErrBadTaskEvent
+	}
+	tags := []string{}
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:   currentEvent.TaskID,
+		Category: &category,
+		Tags:     &tags,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitKind)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+func (t *TaskUsecase) StartTaskCategoryEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditCategory)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
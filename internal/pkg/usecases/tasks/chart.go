@@ -0,0 +1,137 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/chart"
+)
+
+// chartMonths is how many months the chart shows including the current one
+const chartMonths = 6
+
+// taskOccurrences pairs closings of the task with the dates they were due,
+// the first one has no known due date and the next one is not closed yet,
+// due dates follow the schedule of the task as if it was last closed at the previous closing
+func taskOccurrences(task entities.UserTask, history []entities.HistoryRecord) []chart.Occurrence {
+	var res []chart.Occurrence
+	var previous time.Time
+	for _, record := range history {
+		if record.Kind != entities.HistoryCompleted && record.Kind != entities.HistorySkipped {
+			continue
+		}
+		if !previous.IsZero() && task.Regularity > 0 {
+			closed := task
+			closed.LastReminded = previous
+			due := closed.NextDue()
+			res = append(res, chart.Occurrence{
+				Due:     due,
+				Done:    record.DoneAt,
+				Skipped: record.Kind == entities.HistorySkipped,
+				Late:    record.DoneAt.Sub(due) > lateGrace,
+			})
+		}
+		previous = record.DoneAt
+	}
+	if !task.OneOff && !task.Paused() {
+		res = append(res, chart.Occurrence{Due: task.NextDue()})
+	}
+	return res
+}
+
+// memberBars counts completions per member and month starting from the month of from,
+// members are ordered by the number of completions
+func memberBars(history []entities.HistoryRecord, from time.Time, months int) (chart.Bars, []entities.Member) {
+	bars := chart.Bars{}
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < months; i++ {
+		bars.Months = append(bars.Months, start.AddDate(0, i, 0))
+	}
+	counts := map[int64][]int{}
+	totals := map[int64]int{}
+	names := map[int64]string{}
+	var order []int64
+	for _, record := range history {
+		if record.Kind != entities.HistoryCompleted || record.DoneAt.Before(start) {
+			continue
+		}
+		doneAt := record.DoneAt.UTC()
+		month := (doneAt.Year()-start.Year())*12 + int(doneAt.Month()-start.Month())
+		if month >= months {
+			continue
+		}
+		if _, ok := counts[record.UserID]; !ok {
+			counts[record.UserID] = make([]int, months)
+			order = append(order, record.UserID)
+		}
+		counts[record.UserID][month]++
+		totals[record.UserID]++
+		if record.UserName != "" {
+			names[record.UserID] = record.UserName
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return totals[order[i]] > totals[order[j]]
+	})
+	var members []entities.Member
+	for _, userID := range order {
+		bars.Values = append(bars.Values, counts[userID])
+		members = append(members, entities.Member{ID: userID, Name: names[userID]})
+	}
+	return bars, members
+}
+
+// TaskChart renders completions of the task from the message against its due dates
+// and monthly completions per member, without a task the chart is of the whole chat
+func (t *TaskUsecase) TaskChart(ctx context.Context, chatID int64, message string) (entities.Chart, error) {
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return entities.Chart{}, errors.Join(ErrGetHistory, err)
+	}
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-chartMonths, 0)
+
+	res := entities.Chart{}
+	var timeline *chart.Timeline
+	if strings.TrimSpace(message) != "" {
+		task, _, err := t.findTask(ctx, chatID, message)
+		if err != nil {
+			return entities.Chart{}, err
+		}
+		res.Task = task
+		var taskHistory []entities.HistoryRecord
+		for _, record := range history {
+			if record.TaskID == task.ID {
+				taskHistory = append(taskHistory, record)
+			}
+		}
+		history = taskHistory
+		timeline = &chart.Timeline{From: from, To: now}
+		for _, o := range taskOccurrences(task, history) {
+			if o.Due.Before(from) {
+				continue
+			}
+			timeline.To = maxTime(timeline.To, o.Due)
+			timeline.Occurrences = append(timeline.Occurrences, o)
+		}
+		timeline.To = timeline.To.Add(3 * 24 * time.Hour)
+	}
+	bars, members := memberBars(history, from, chartMonths)
+	res.Members = members
+	res.Image, err = chart.EncodePNG(timeline, &bars)
+	if err != nil {
+		return entities.Chart{}, errors.Join(ErrRenderChart, err)
+	}
+	return res, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
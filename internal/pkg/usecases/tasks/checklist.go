@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/repos/sqlite_repo"
+)
+
+// parseChecklist takes an item per line, list markers like "-", "•" and "1." are dropped
+func parseChecklist(message string) []string {
+	var items []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-•*–— ")
+		if i := strings.IndexAny(line, ".)"); i > 0 && strings.Trim(line[:i], "0123456789") == "" {
+			line = line[i+1:]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// StartTaskChecklistEdit waits for the checklist of the edited task
+func (t *TaskUsecase) StartTaskChecklistEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditChecklist)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// editChecklist replaces the checklist with the lines of message, clear answer removes it
+func (t *TaskUsecase) editChecklist(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	var items []string
+	if !clearNotesAnswers[answer(message)] {
+		items = parseChecklist(message)
+		if len(items) == 0 {
+			return entities.NewEmptyTaskMessageResult(), ErrEmptyChecklist
+		}
+	}
+	err := t.ts.SetChecklist(ctx, event.TaskID, items)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateChecklist, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditChecklistTaskResult(), nil
+}
+
+func (t *TaskUsecase) GetTaskChecklist(ctx context.Context, taskID int64) ([]entities.ChecklistItem, error) {
+	items, err := t.ts.GetChecklist(ctx, taskID)
+	if err != nil {
+		return nil, errors.Join(ErrGetChecklist, err)
+	}
+	return items, nil
+}
+
+// ToggleChecklistItem ticks or unticks the item of the reminded task and returns the checklist,
+// the task is completed with CompleteTask once every item is ticked
+func (t *TaskUsecase) ToggleChecklistItem(ctx context.Context, chatID int64, member entities.Member, itemID int64) ([]entities.ChecklistItem, error) {
+	taskEvent, err := t.getRemindEvent(ctx, chatID)
+	if errors.Is(err, sqlite_repo.ErrNoTaskEvent) || errors.Is(err, ErrBadTaskEvent) {
+		return nil, errors.Join(entities.ErrReminderClosed, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	items, err := t.GetTaskChecklist(ctx, taskEvent.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for i := range items {
+		if items[i].ID != itemID {
+			continue
+		}
+		found = true
+		items[i].Done = !items[i].Done
+		err = t.ts.SetChecklistItemDone(ctx, itemID, items[i].Done)
+		if err != nil {
+			return nil, errors.Join(ErrUpdateChecklist, err)
+		}
+	}
+	// the button is left from another reminder
+	if !found {
+		return nil, entities.ErrReminderClosed
+	}
+	if entities.ChecklistDone(items) {
+		return items, t.CompleteTask(ctx, chatID, member)
+	}
+	return items, nil
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"house-timer/internal/pkg/entities"
+)
+
+var counterAnswers = map[string]bool{
+	"по счетчику":      true,
+	"по счётчику":      true,
+	"счетчик":          true,
+	"по пробегу":       true,
+	"по использованию": true,
+}
+
+// predictionPeriod is how far back readings are taken to estimate the usage rate
+const predictionPeriod = 90 * 24 * time.Hour
+
+// parseAmount reads "5000 км", "120,5л" or "300" into the value and the unit
+func parseAmount(message string) (float64, string, error) {
+	message = strings.TrimSpace(message)
+	end := strings.IndexFunc(message, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if end == -1 {
+		end = len(message)
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(message[:end], ",", "."), 64)
+	if err != nil {
+		return 0, "", errors.Join(ErrParseAmount, err)
+	}
+	if value <= 0 {
+		return 0, "", errors.Join(ErrParseAmount, fmt.Errorf("not positive amount %v", value))
+	}
+	return value, strings.TrimSpace(message[end:]), nil
+}
+
+// ChooseCounterKind continues creation with asking the threshold of a task due by usage
+func (t *TaskUsecase) ChooseCounterKind(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationWaitKind {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitThreshold)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// handleThresholdMessage sets the threshold and asks the regularity used as the time fallback
+func (t *TaskUsecase) handleThresholdMessage(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	threshold, unit, err := parseAmount(message)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), err
+	}
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:      event.TaskID,
+		Threshold:   &threshold,
+		CounterUnit: &unit,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitRegularity)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewNeedRegularityResult(), nil
+}
+
+// predictCounter estimates when the threshold is reached from the rate of readings,
+// zero time is returned while there are too few readings
+func predictCounter(task entities.UserTask, readings []entities.Reading, now time.Time) time.Time {
+	if task.CounterReached() {
+		return now
+	}
+	if len(readings) < 2 {
+		return time.Time{}
+	}
+	// the first reading is the usage before it, so it is not counted in the rate
+	span := now.Sub(readings[0].LoggedAt)
+	if span < 24*time.Hour {
+		return time.Time{}
+	}
+	total := 0.0
+	for _, reading := range readings[1:] {
+		total += reading.Value
+	}
+	if total <= 0 {
+		return time.Time{}
+	}
+	perSecond := total / span.Seconds()
+	left := time.Duration((task.Threshold - task.Counter) / perSecond * float64(time.Second))
+	return now.Add(left)
+}
+
+// LogCounter adds usage to the counter task: "поменять фильтр 120", "2 350 км",
+// the task is made due once the threshold is reached, returns the updated task
+// and the predicted date of reaching the threshold or zero time if it is unknown yet
+func (t *TaskUsecase) LogCounter(ctx context.Context, chatID int64, message string) (entities.UserTask, time.Time, error) {
+	task, rest, err := t.findTask(ctx, chatID, message)
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, err
+	}
+	if !task.CounterTask() {
+		return entities.UserTask{}, time.Time{}, ErrNotCounterTask
+	}
+	value, _, err := parseAmount(rest)
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, err
+	}
+	now := time.Now()
+	_, err = t.ts.AddReading(ctx, entities.Reading{
+		TaskID:   task.ID,
+		Value:    value,
+		LoggedAt: now,
+	})
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, errors.Join(ErrAddReading, err)
+	}
+	task.Counter += value
+	update := entities.TaskUpdate{
+		TaskID:  task.ID,
+		Counter: &task.Counter,
+	}
+	// the task is due from the day the threshold is reached, readings after a skip make it due again
+	if task.CounterReached() && task.CounterReachedAt.IsZero() {
+		task.CounterReachedAt = now
+		update.CounterReachedAt = &task.CounterReachedAt
+	}
+	err = t.ts.UpdateTask(ctx, update)
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, errors.Join(ErrUpdateTask, err)
+	}
+	readings, err := t.ts.GetReadings(ctx, task.ID, now.Add(-predictionPeriod))
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, errors.Join(ErrGetReadings, err)
+	}
+	return task, predictCounter(task, readings, now), nil
+}
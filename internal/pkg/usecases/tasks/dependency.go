@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+var noDependencyAnswers = map[string]bool{
+	"нет":         true,
+	"убрать":      true,
+	"ни от чего":  true,
+	"независимая": true,
+}
+
+func (t *TaskUsecase) StartTaskDependencyEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditDependency)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// makesCycle reports whether taskID is reached following dependencies from afterTaskID
+func makesCycle(tasks []entities.UserTask, taskID int64, afterTaskID int64) bool {
+	byID := map[int64]entities.UserTask{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	seen := map[int64]bool{}
+	for id := afterTaskID; id != 0 && !seen[id]; id = byID[id].AfterTaskID {
+		if id == taskID {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+// editDependency takes the task to follow and the delay after its completion:
+// "3 через 1 день", "разморозить морозилку через 2 дня" or "нет" to remove the dependency
+func (t *TaskUsecase) editDependency(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	if confirmsGuess(event, message) {
+		return t.acceptGuess(ctx, event, chatID, entities.NewGotEditDependencyTaskResult())
+	}
+	afterTaskID := int64(0)
+	delay := time.Duration(0)
+	guessed := false
+	if !noDependencyAnswers[answer(message)] {
+		after, rest, err := t.findTask(ctx, chatID, message)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), err
+		}
+		if rest != "" {
+			delay, guessed, err = t.parseGuarded(ctx, event, rest)
+			if err != nil {
+				return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseRegularity, err)
+			}
+		}
+		tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrGetTasks, err)
+		}
+		if makesCycle(tasks, event.TaskID, after.ID) {
+			return entities.NewEmptyTaskMessageResult(), ErrDependencyCycle
+		}
+		afterTaskID = after.ID
+	}
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:      event.TaskID,
+		AfterTaskID: &afterTaskID,
+		AfterDelay:  &delay,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	if guessed {
+		return entities.NewNeedDelayConfirmResult(), nil
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditDependencyTaskResult(), nil
+}
+
+// scheduleDependents makes tasks following the completed one due after their delay
+func (t *TaskUsecase) scheduleDependents(ctx context.Context, chatID int64, taskID int64, doneAt time.Time) error {
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return errors.Join(ErrGetTasks, err)
+	}
+	for _, task := range tasks {
+		if task.AfterTaskID != taskID {
+			continue
+		}
+		chainedAt := doneAt.Add(task.AfterDelay)
+		err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+			TaskID:    task.ID,
+			ChainedAt: &chainedAt,
+		})
+		if err != nil {
+			return errors.Join(ErrUpdateTask, err)
+		}
+	}
+	return nil
+}
+
+// GetTaskChain returns the tasks the edited one follows, the task itself
+// and the tasks following it, in the order they are done
+func (t *TaskUsecase) GetTaskChain(ctx context.Context, chatID int64) ([]entities.UserTask, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if currentEvent.Type != entities.TaskEditEvent {
+		return nil, ErrBadTaskEvent
+	}
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return nil, errors.Join(ErrGetTasks, err)
+	}
+	byID := map[int64]entities.UserTask{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	current, ok := byID[currentEvent.TaskID]
+	if !ok {
+		return nil, ErrBadTaskEvent
+	}
+
+	var chain []entities.UserTask
+	seen := map[int64]bool{current.ID: true}
+	for id := current.AfterTaskID; id != 0 && !seen[id]; id = byID[id].AfterTaskID {
+		before, ok := byID[id]
+		// the task followed may be deleted
+		if !ok {
+			break
+		}
+		seen[id] = true
+		chain = append([]entities.UserTask{before}, chain...)
+	}
+	chain = append(chain, current)
+	var addFollowing func(id int64)
+	addFollowing = func(id int64) {
+		for _, task := range tasks {
+			if task.AfterTaskID == id && !seen[task.ID] {
+				seen[task.ID] = true
+				chain = append(chain, task)
+				addFollowing(task.ID)
+			}
+		}
+	}
+	addFollowing(current.ID)
+	return chain, nil
+}
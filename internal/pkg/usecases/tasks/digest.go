@@ -0,0 +1,140 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+var digestOffAnswers = map[string]bool{
+	"выкл":      true,
+	"выключить": true,
+	"нет":       true,
+	"стоп":      true,
+	"off":       true,
+}
+
+// defaultDigestHour is used when the message has no hour
+const defaultDigestHour = 6
+
+// parseDigest understands "каждый день в 9", "по понедельникам в 10", "еженедельно"
+// and "только" to send the digest instead of separate reminders
+func parseDigest(message string) (entities.DigestSettings, error) {
+	message = answer(message)
+	if digestOffAnswers[message] {
+		return entities.DigestSettings{Period: entities.DigestOff}, nil
+	}
+	settings := entities.DigestSettings{Hour: defaultDigestHour}
+	for _, word := range strings.Fields(message) {
+		hour, _, _ := strings.Cut(word, ":")
+		if h, err := strconv.Atoi(hour); err == nil {
+			if h < 0 || h > 23 {
+				return entities.DigestSettings{}, ErrParseDigest
+			}
+			settings.Hour = h
+		} else if day, ok := regularity.ParseWeekday(word); ok {
+			settings.Period = entities.DigestWeekly
+			settings.Weekday = day
+		} else if strings.HasPrefix(word, "недел") || strings.HasPrefix(word, "еженедел") {
+			if settings.Period == entities.DigestOff {
+				settings.Period = entities.DigestWeekly
+				settings.Weekday = time.Monday
+			}
+		} else if strings.HasPrefix(word, "ден") || strings.HasPrefix(word, "ежеднев") {
+			settings.Period = entities.DigestDaily
+		} else if word == "только" {
+			settings.Only = true
+		}
+	}
+	if settings.Period == entities.DigestOff {
+		return entities.DigestSettings{}, ErrParseDigest
+	}
+	return settings, nil
+}
+
+// SetDigest saves digest settings of the chat from the message, the first digest is sent at the next time
+func (t *TaskUsecase) SetDigest(ctx context.Context, chatID int64, message string) (entities.DigestSettings, error) {
+	settings, err := parseDigest(message)
+	if err != nil {
+		return entities.DigestSettings{}, err
+	}
+	err = t.cs.SetDigest(ctx, chatID, settings)
+	if err != nil {
+		return entities.DigestSettings{}, errors.Join(ErrSetDigest, err)
+	}
+	err = t.cs.SetDigestSentAt(ctx, chatID, time.Now())
+	if err != nil {
+		return entities.DigestSettings{}, errors.Join(ErrSetDigest, err)
+	}
+	return settings, nil
+}
+
+// BuildDigest collects overdue and upcoming tasks of the chat and the ones done during the last period
+func (t *TaskUsecase) BuildDigest(ctx context.Context, chatID int64, now time.Time) (entities.Digest, error) {
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return entities.Digest{}, errors.Join(ErrGetChat, err)
+	}
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return entities.Digest{}, errors.Join(ErrGetTasks, err)
+	}
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return entities.Digest{}, errors.Join(ErrGetHistory, err)
+	}
+
+	period := chat.Digest.Period.Duration()
+	// today starts at the local midnight of the chat
+	offset := chat.Quiet.Offset
+	today := now.Add(offset).Truncate(24 * time.Hour).Add(-offset)
+	digest := entities.Digest{Period: chat.Digest.Period}
+	for _, task := range tasks {
+		if task.Paused() {
+			continue
+		}
+		due := task.NextDue()
+		if due.Before(today) {
+			digest.Overdue = append(digest.Overdue, task)
+		} else if due.Before(today.Add(period)) {
+			digest.Upcoming = append(digest.Upcoming, task)
+		}
+	}
+
+	done := map[int64]int{}
+	var order []int64
+	for _, record := range history {
+		if record.Kind != entities.HistoryCompleted || record.DoneAt.Before(now.Add(-period)) || record.DoneAt.After(now) {
+			continue
+		}
+		if done[record.TaskID] == 0 {
+			order = append(order, record.TaskID)
+		}
+		done[record.TaskID]++
+	}
+	for _, taskID := range order {
+		// completed one-off tasks are archived and not in the list
+		task, err := t.ts.GetTask(ctx, taskID)
+		if err != nil {
+			return entities.Digest{}, errors.Join(ErrGetTasks, err)
+		}
+		if !task.DeletedAt.IsZero() {
+			continue
+		}
+		digest.Done = append(digest.Done, entities.DigestDone{Task: task, Times: done[taskID]})
+	}
+	return digest, nil
+}
+
+func (t *TaskUsecase) MarkDigestSent(ctx context.Context, chatID int64, at time.Time) error {
+	err := t.cs.SetDigestSentAt(ctx, chatID, at)
+	if err != nil {
+		return errors.Join(ErrSetDigest, err)
+	}
+	return nil
+}
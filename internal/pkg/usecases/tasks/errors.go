@@ -45,3 +45,73 @@ var ErrGetCurrentTaskEvent = errors.New("failed to get current task event")
 var ErrGetTasks = errors.New("failed to get tasks")
 
 var ErrUpdateTask = errors.New("failed to update task")
+
+var ErrAddHistory = errors.New("failed to add history record")
+
+var ErrGetHistory = errors.New("failed to get history")
+
+var ErrAddSnooze = errors.New("failed to add snooze")
+
+var ErrGetSnoozes = errors.New("failed to get snoozes")
+
+var ErrGetChat = errors.New("failed to get chat")
+
+var ErrInvalidImport = errors.New("invalid import")
+
+var ErrImport = errors.New("failed to import")
+
+var ErrNotInTrash = errors.New("task is not in trash")
+
+var ErrUndoExpired = errors.New("undo expired")
+
+var ErrPurgeTrash = errors.New("failed to purge trash")
+
+var ErrPauseTask = errors.New("failed to pause task")
+
+var ErrParseVacation = errors.New("failed to parse vacation end")
+
+var ErrNoVacation = errors.New("chat is not on vacation")
+
+var ErrSetVacation = errors.New("failed to set vacation")
+
+var ErrParseDate = errors.New("failed to parse date")
+
+var ErrUnknownTask = errors.New("unknown task")
+
+var ErrOneOffTask = errors.New("not available for one-off task")
+
+var ErrAddAttachment = errors.New("failed to add attachment")
+
+var ErrGetAttachments = errors.New("failed to get attachments")
+
+var ErrGetProofs = errors.New("failed to get proofs")
+
+var ErrUpdateChecklist = errors.New("failed to update checklist")
+
+var ErrGetChecklist = errors.New("failed to get checklist")
+
+var ErrEmptyChecklist = errors.New("checklist is empty")
+
+var ErrDependencyCycle = errors.New("dependency makes a cycle")
+
+var ErrParseAmount = errors.New("failed to parse amount")
+
+var ErrNotCounterTask = errors.New("task is not due by counter")
+
+var ErrAddReading = errors.New("failed to add reading")
+
+var ErrGetReadings = errors.New("failed to get readings")
+
+var ErrParseSeason = errors.New("failed to parse season")
+
+var ErrParseDigest = errors.New("failed to parse digest settings")
+
+var ErrSetDigest = errors.New("failed to set digest")
+
+var ErrParseQuiet = errors.New("failed to parse quiet hours")
+
+var ErrSetQuiet = errors.New("failed to set quiet hours")
+
+var ErrRenderChart = errors.New("failed to render chart")
+
+var ErrParsePoints = errors.New("failed to parse points")
@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+// setGuess remembers in the event payload that the value of the current step was guessed,
+// so that the next "да" at the same step confirms it
+func (t *TaskUsecase) setGuess(ctx context.Context, event *entities.UserTaskEvent, guessed bool) error {
+	payload := ""
+	if guessed {
+		payload = string(event.Step)
+	}
+	if event.Payload == payload {
+		return nil
+	}
+	err := t.tes.SetPayload(ctx, event.ID, payload)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// parseGuarded parses a duration with regularity.Parse and tells if it was guessed
+func (t *TaskUsecase) parseGuarded(ctx context.Context, event *entities.UserTaskEvent, message string) (time.Duration, bool, error) {
+	res, err := regularity.Parse(message)
+	if err != nil {
+		return 0, false, err
+	}
+	guessed := res.Confidence < minRegularityConfidence
+	if err := t.setGuess(ctx, event, guessed); err != nil {
+		return 0, false, err
+	}
+	return res.Duration, guessed, nil
+}
+
+// confirmsGuess tells if message accepts the value guessed at the current step,
+// the value is already saved so only the step has to move on
+func confirmsGuess(event *entities.UserTaskEvent, message string) bool {
+	return event.Payload == string(event.Step) && isConfirmation(message)
+}
+
+// acceptGuess finishes the edit step confirmed with "да"
+func (t *TaskUsecase) acceptGuess(ctx context.Context, event *entities.UserTaskEvent, chatID int64, res entities.TaskMessageResult) (entities.TaskMessageResult, error) {
+	err := t.setGuess(ctx, event, false)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), err
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return res, nil
+}
+
+// parseDueDate reads a date ("15.11") or the time left ("через 2 недели"),
+// the time left goes through parseGuarded and may need a confirmation
+func (t *TaskUsecase) parseDueDate(ctx context.Context, event *entities.UserTaskEvent, message string, now time.Time) (time.Time, bool, error) {
+	dueAt, err := regularity.ParseFutureDate(message, now)
+	if err == nil {
+		return dueAt, false, t.setGuess(ctx, event, false)
+	}
+	dur, guessed, durErr := t.parseGuarded(ctx, event, message)
+	if durErr != nil {
+		return time.Time{}, false, errors.Join(ErrParseDate, err, durErr)
+	}
+	return now.Add(dur), guessed, nil
+}
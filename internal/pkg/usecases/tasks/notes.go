@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"house-timer/internal/pkg/entities"
+)
+
+var clearNotesAnswers = map[string]bool{
+	"очистить": true,
+	"удалить":  true,
+	"убрать":   true,
+}
+
+// StartTaskNotesEdit waits for notes and attachments of the edited task until FinishTaskNotesEdit
+func (t *TaskUsecase) StartTaskNotesEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditNotes)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// editNotes replaces notes of the task, clear answer removes notes together with attachments
+func (t *TaskUsecase) editNotes(ctx context.Context, event *entities.UserTaskEvent, message string) (entities.TaskMessageResult, error) {
+	notes := strings.TrimSpace(message)
+	cleared := clearNotesAnswers[answer(message)]
+	if cleared {
+		notes = ""
+		err := t.ts.DeleteAttachments(ctx, event.TaskID)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+		}
+	}
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID: event.TaskID,
+		Notes:  &notes,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	if cleared {
+		return entities.NewClearedNotesTaskResult(), nil
+	}
+	return entities.NewGotEditNotesTaskResult(), nil
+}
+
+func (t *TaskUsecase) AddTaskAttachment(ctx context.Context, chatID int64, fileID string, kind entities.AttachmentKind) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditNotes {
+		return ErrBadTaskEvent
+	}
+	_, err = t.ts.AddAttachment(ctx, entities.Attachment{
+		TaskID: currentEvent.TaskID,
+		FileID: fileID,
+		Kind:   kind,
+	})
+	if err != nil {
+		return errors.Join(ErrAddAttachment, err)
+	}
+	return nil
+}
+
+// FinishTaskNotesEdit returns to the edit menu
+func (t *TaskUsecase) FinishTaskNotesEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditNotes {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+func (t *TaskUsecase) GetTaskAttachments(ctx context.Context, taskID int64) ([]entities.Attachment, error) {
+	attachments, err := t.ts.GetAttachments(ctx, taskID)
+	if err != nil {
+		return nil, errors.Join(ErrGetAttachments, err)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,220 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+var repeatAnswers = map[string]bool{
+	"повторять":     true,
+	"регулярно":     true,
+	"повторяющаяся": true,
+	"много раз":     true,
+}
+
+var onceAnswers = map[string]bool{
+	"один раз":   true,
+	"1 раз":      true,
+	"однократно": true,
+	"разово":     true,
+	"разовая":    true,
+}
+
+var noDeadlineAnswers = map[string]bool{
+	"нет":       true,
+	"убрать":    true,
+	"без срока": true,
+}
+
+func answer(message string) string {
+	return strings.Join(strings.Fields(strings.Trim(strings.ToLower(message), " .!")), " ")
+}
+
+// ChooseTaskKind continues creation with asking the regularity or the date of a one-off task
+func (t *TaskUsecase) ChooseTaskKind(ctx context.Context, chatID int64, oneOff bool) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationWaitKind {
+		return ErrBadTaskEvent
+	}
+	step := entities.TaskCreationWaitRegularity
+	if oneOff {
+		step = entities.TaskCreationWaitDueDate
+	}
+	err = t.tes.UpdateStep(ctx, chatID, step)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+func (t *TaskUsecase) createOneOff(ctx context.Context, event *entities.UserTaskEvent, dueAt time.Time) (entities.TaskMessageResult, error) {
+	err := t.ts.CreateTaskDueDate(ctx, event.TaskID, dueAt)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskRegularity, err)
+	}
+	return t.finishCreation(ctx, event)
+}
+
+// handleDueDateMessage creates the one-off task, a guessed time left is saved
+// and waits for "да" before the task is created
+func (t *TaskUsecase) handleDueDateMessage(ctx context.Context, event *entities.UserTaskEvent, message string) (entities.TaskMessageResult, error) {
+	if confirmsGuess(event, message) {
+		return t.finishCreation(ctx, event)
+	}
+	dueAt, guessed, err := t.parseDueDate(ctx, event, message, time.Now())
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), err
+	}
+	if !guessed {
+		return t.createOneOff(ctx, event, dueAt)
+	}
+	err = t.ts.CreateTaskDueDate(ctx, event.TaskID, dueAt)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskRegularity, err)
+	}
+	return entities.NewNeedDueDateConfirmResult(), nil
+}
+
+// handleKindMessage takes the answer to "повторять или один раз?",
+// a regularity or an explicit date given right away are accepted too,
+// "через 2 недели" reads as a regularity and is confirmed like one
+func (t *TaskUsecase) handleKindMessage(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	switch {
+	case repeatAnswers[answer(message)]:
+		err := t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitRegularity)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.NewNeedRegularityResult(), nil
+	case onceAnswers[answer(message)]:
+		err := t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitDueDate)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.NewNeedDueDateResult(), nil
+	case counterAnswers[answer(message)]:
+		err := t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitThreshold)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.NewNeedThresholdResult(), nil
+	}
+	if dueAt, err := regularity.ParseFutureDate(message, time.Now()); err == nil {
+		return t.createOneOff(ctx, event, dueAt)
+	}
+	return t.handleRegularityMessage(ctx, event, chatID, message)
+}
+
+func (t *TaskUsecase) StartTaskDueDateEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditDueDate)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// editDueDate moves the date of a one-off task or sets the deadline of a recurring one,
+// the deadline is removed with "нет"
+func (t *TaskUsecase) editDueDate(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	if confirmsGuess(event, message) {
+		return t.acceptGuess(ctx, event, chatID, entities.NewGotEditDueDateTaskResult())
+	}
+	task, err := t.ts.GetTask(ctx, event.TaskID)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrGetTasks, err)
+	}
+	var dueAt time.Time
+	guessed := false
+	if !noDeadlineAnswers[answer(message)] || task.OneOff {
+		dueAt, guessed, err = t.parseDueDate(ctx, event, message, time.Now())
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), err
+		}
+	}
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID: task.ID,
+		DueAt:  &dueAt,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	if guessed {
+		return entities.NewNeedDueDateConfirmResult(), nil
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditDueDateTaskResult(), nil
+}
+
+// finishOccurrence resets the checklist, archives completed one-off tasks,
+// drops the date set by the task followed and the met deadline of recurring ones, the counter starts over after a completion
+// while a skipped occurrence keeps the usage and only waits for the next reading
+func (t *TaskUsecase) finishOccurrence(ctx context.Context, taskID int64, kind entities.HistoryKind) error {
+	task, err := t.ts.GetTask(ctx, taskID)
+	if err != nil {
+		return errors.Join(ErrGetTasks, err)
+	}
+	// the next occurrence starts with an unticked checklist
+	err = t.ts.ResetChecklist(ctx, taskID)
+	if err != nil {
+		return errors.Join(ErrUpdateChecklist, err)
+	}
+	if !task.ChainedAt.IsZero() {
+		err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+			TaskID:    taskID,
+			ChainedAt: &time.Time{},
+		})
+		if err != nil {
+			return errors.Join(ErrUpdateTask, err)
+		}
+	}
+	if task.OneOff {
+		err = t.ts.ArchiveTask(ctx, taskID)
+		if err != nil {
+			return errors.Join(ErrUpdateTask, err)
+		}
+		return nil
+	}
+	if task.CounterTask() {
+		update := entities.TaskUpdate{
+			TaskID:           taskID,
+			CounterReachedAt: &time.Time{},
+		}
+		if kind == entities.HistoryCompleted {
+			noUsage := 0.0
+			update.Counter = &noUsage
+		}
+		err = t.ts.UpdateTask(ctx, update)
+		if err != nil {
+			return errors.Join(ErrUpdateTask, err)
+		}
+	}
+	if kind == entities.HistoryCompleted && !task.DueAt.IsZero() {
+		noDeadline := time.Time{}
+		err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+			TaskID: taskID,
+			DueAt:  &noDeadline,
+		})
+		if err != nil {
+			return errors.Join(ErrUpdateTask, err)
+		}
+	}
+	return nil
+}
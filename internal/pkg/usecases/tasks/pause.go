@@ -0,0 +1,134 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+// shiftTask moves the due date of the task later by shift
+func (t *TaskUsecase) shiftTask(ctx context.Context, task entities.UserTask, shift time.Duration) error {
+	lastReminded := task.LastReminded.Add(shift)
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:       task.ID,
+		LastReminded: &lastReminded,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	return nil
+}
+
+// ToggleTaskPause pauses the edited task or resumes it shifting the due date
+// by the time it was paused, returns the updated task
+func (t *TaskUsecase) ToggleTaskPause(ctx context.Context, chatID int64) (entities.UserTask, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.UserTask{}, err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return entities.UserTask{}, ErrBadTaskEvent
+	}
+	task, err := t.ts.GetTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	now := time.Now()
+	if task.Paused() {
+		err = t.shiftTask(ctx, task, now.Sub(task.PausedAt))
+		if err != nil {
+			return entities.UserTask{}, err
+		}
+		err = t.ts.ResumeTask(ctx, task.ID)
+	} else {
+		err = t.ts.PauseTask(ctx, task.ID, now)
+	}
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrPauseTask, err)
+	}
+	task, err = t.ts.GetTask(ctx, task.ID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	return task, nil
+}
+
+func (t *TaskUsecase) GetChat(ctx context.Context, chatID int64) (entities.Chat, error) {
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return entities.Chat{}, errors.Join(ErrGetChat, err)
+	}
+	return chat, nil
+}
+
+// StartVacation suspends reminders of the chat until the date ("25.10")
+// or for the duration ("2 недели") given in message, a running vacation is extended
+func (t *TaskUsecase) StartVacation(ctx context.Context, chatID int64, message string) (time.Time, error) {
+	now := time.Now()
+	until, err := regularity.ParseFutureDate(message, now)
+	if err != nil {
+		dur, durErr := regularity.ExtractRegularity(message)
+		if durErr != nil {
+			return time.Time{}, errors.Join(ErrParseVacation, err, durErr)
+		}
+		until = now.Add(dur)
+	}
+	if !until.After(now) {
+		return time.Time{}, ErrParseVacation
+	}
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return time.Time{}, errors.Join(ErrGetChat, err)
+	}
+	from := now
+	if chat.OnVacation(now) {
+		from = chat.VacationFrom
+	}
+	err = t.cs.SetVacation(ctx, chatID, from, until)
+	if err != nil {
+		return time.Time{}, errors.Join(ErrSetVacation, err)
+	}
+	return until, nil
+}
+
+// EndVacation closes the vacation of the chat, now or when it is over,
+// and shifts due dates of the tasks by its length, returns the shift
+func (t *TaskUsecase) EndVacation(ctx context.Context, chatID int64) (time.Duration, error) {
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return 0, errors.Join(ErrGetChat, err)
+	}
+	if chat.VacationFrom.IsZero() {
+		return 0, ErrNoVacation
+	}
+	end := time.Now()
+	if chat.VacationUntil.Before(end) {
+		end = chat.VacationUntil
+	}
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return 0, errors.Join(ErrGetTasks, err)
+	}
+	for _, task := range tasks {
+		shiftEnd := end
+		// paused tasks are shifted on resume for the time they were paused
+		if task.Paused() && task.PausedAt.Before(end) {
+			shiftEnd = task.PausedAt
+		}
+		shift := shiftEnd.Sub(chat.VacationFrom)
+		if shift <= 0 {
+			continue
+		}
+		if err := t.shiftTask(ctx, task, shift); err != nil {
+			return 0, err
+		}
+	}
+	err = t.cs.ClearVacation(ctx, chatID)
+	if err != nil {
+		return 0, errors.Join(ErrSetVacation, err)
+	}
+	return end.Sub(chat.VacationFrom), nil
+}
@@ -0,0 +1,126 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+var defaultPointsAnswers = map[string]bool{
+	"авто":         true,
+	"по умолчанию": true,
+	"сбросить":     true,
+}
+
+const (
+	// minDecay is the part of points left however late the task is done
+	minDecay = 0.25
+	// oneOffDecayPeriod stands for the regularity of one-off tasks, they lose all points in it
+	oneOffDecayPeriod = 7 * 24 * time.Hour
+)
+
+// awardPoints returns points for completing the task at doneAt, a completion later
+// than the grace day loses points in proportion to the lateness relative to the regularity
+func awardPoints(task entities.UserTask, doneAt time.Time) int {
+	points := task.TaskPoints()
+	period := task.Regularity
+	if task.OneOff || period <= 0 {
+		period = oneOffDecayPeriod
+	}
+	due := task.NextDue()
+	if due.IsZero() {
+		return points
+	}
+	late := doneAt.Sub(due) - lateGrace
+	if late <= 0 {
+		return points
+	}
+	decay := math.Max(minDecay, 1-float64(late)/float64(period))
+	return max(1, int(math.Round(float64(points)*decay)))
+}
+
+func (t *TaskUsecase) StartTaskPointsEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditPoints)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// editPoints takes the number of points or "авто" for the default ones
+func (t *TaskUsecase) editPoints(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	points := 0
+	if !defaultPointsAnswers[answer(message)] {
+		var err error
+		points, err = strconv.Atoi(answer(message))
+		if err != nil || points <= 0 || points > entities.MaxTaskPoints {
+			return entities.NewEmptyTaskMessageResult(), ErrParsePoints
+		}
+	}
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID: event.TaskID,
+		Points: &points,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditPointsTaskResult(), nil
+}
+
+// rankMembers sums points of completions done after since
+func rankMembers(history []entities.HistoryRecord, since time.Time) []entities.MemberPoints {
+	byMember := map[int64]*entities.MemberPoints{}
+	var order []int64
+	for _, record := range history {
+		if record.Kind != entities.HistoryCompleted || record.Points == 0 || record.DoneAt.Before(since) {
+			continue
+		}
+		member, ok := byMember[record.UserID]
+		if !ok {
+			member = &entities.MemberPoints{Member: entities.Member{ID: record.UserID}}
+			byMember[record.UserID] = member
+			order = append(order, record.UserID)
+		}
+		// the latest name wins
+		if record.UserName != "" {
+			member.Member.Name = record.UserName
+		}
+		member.Points += record.Points
+	}
+	res := make([]entities.MemberPoints, 0, len(order))
+	for _, userID := range order {
+		res = append(res, *byMember[userID])
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Points > res[j].Points
+	})
+	return res
+}
+
+// GetLeaderboard ranks members of the chat by points of the last week and of all time
+func (t *TaskUsecase) GetLeaderboard(ctx context.Context, chatID int64) (entities.Leaderboard, error) {
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return entities.Leaderboard{}, errors.Join(ErrGetHistory, err)
+	}
+	return entities.Leaderboard{
+		Week:    rankMembers(history, time.Now().Add(-7*24*time.Hour)),
+		AllTime: rankMembers(history, time.Time{}),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+// maxProofs limits proofs shown for a task
+const maxProofs = 10
+
+// CompleteTaskWithProof completes the reminded task waiting for proof with the photo
+func (t *TaskUsecase) CompleteTaskWithProof(ctx context.Context, chatID int64, member entities.Member, fileID string) error {
+	taskEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if taskEvent.Step != entities.TaskRemindWaitProof {
+		return ErrBadTaskEvent
+	}
+	now := time.Now()
+	err = t.markTask(ctx, member, entities.HistoryRecord{
+		ChatID:      chatID,
+		TaskID:      taskEvent.TaskID,
+		Kind:        entities.HistoryCompleted,
+		DoneAt:      now,
+		ProofFileID: fileID,
+	}, now)
+	if err != nil {
+		return err
+	}
+	err = t.tes.DeleteEvent(ctx, taskEvent.ID)
+	if err != nil {
+		return errors.Join(ErrDeleteEvent, err)
+	}
+	return nil
+}
+
+// ToggleTaskProof switches whether the edited task requires a photo on completion
+func (t *TaskUsecase) ToggleTaskProof(ctx context.Context, chatID int64) (entities.UserTask, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.UserTask{}, err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return entities.UserTask{}, ErrBadTaskEvent
+	}
+	task, err := t.ts.GetTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	task.RequiresProof = !task.RequiresProof
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:        task.ID,
+		RequiresProof: &task.RequiresProof,
+	})
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrUpdateTask, err)
+	}
+	return task, nil
+}
+
+// GetTaskProofs returns the latest completions of the edited task with photo
+func (t *TaskUsecase) GetTaskProofs(ctx context.Context, chatID int64) ([]entities.HistoryRecord, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return nil, ErrBadTaskEvent
+	}
+	proofs, err := t.hs.GetTaskProofs(ctx, currentEvent.TaskID, maxProofs)
+	if err != nil {
+		return nil, errors.Join(ErrGetProofs, err)
+	}
+	return proofs, nil
+}
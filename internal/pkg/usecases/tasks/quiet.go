@@ -0,0 +1,110 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+var quietOffAnswers = map[string]bool{
+	"выкл":      true,
+	"выключить": true,
+	"нет":       true,
+	"стоп":      true,
+	"off":       true,
+}
+
+// parseOffset reads "utc+3", "gmt-5" or "мск"
+func parseOffset(word string) (time.Duration, bool) {
+	if word == "мск" {
+		return 3 * time.Hour, true
+	}
+	if !strings.HasPrefix(word, "utc") && !strings.HasPrefix(word, "gmt") {
+		return 0, false
+	}
+	rest := strings.TrimPrefix(word[3:], "+")
+	if rest == "" {
+		return 0, true
+	}
+	offset, ok := regularity.ParseClock(strings.TrimPrefix(rest, "-"))
+	if !ok || offset > entities.MaxUTCOffset {
+		return 0, false
+	}
+	if strings.HasPrefix(rest, "-") {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// parseQuiet understands "22-8", "с 23 до 7:30 выходные до 10", "utc+3" and "выкл",
+// settings missing in the message are taken from current
+func parseQuiet(message string, current entities.QuietHours) (entities.QuietHours, error) {
+	message = answer(message)
+	for _, dash := range []string{"—", "–"} {
+		message = strings.ReplaceAll(message, dash, "-")
+	}
+	if quietOffAnswers[message] {
+		return entities.QuietHours{Offset: current.Offset}, nil
+	}
+	quiet := current
+	var clocks []time.Duration
+	weekend := false
+	parsed := false
+	for _, word := range strings.Fields(message) {
+		if word == "-" {
+			continue
+		}
+		if offset, ok := parseOffset(word); ok {
+			quiet.Offset = offset
+			parsed = true
+		} else if from, until, ok := strings.Cut(word, "-"); ok {
+			fromClock, fromOk := regularity.ParseClock(from)
+			untilClock, untilOk := regularity.ParseClock(until)
+			if !fromOk || !untilOk {
+				return entities.QuietHours{}, ErrParseQuiet
+			}
+			clocks = append(clocks, fromClock, untilClock)
+		} else if clock, ok := regularity.ParseClock(word); ok {
+			if weekend {
+				quiet.WeekendUntil = clock
+				weekend = false
+				parsed = true
+			} else {
+				clocks = append(clocks, clock)
+			}
+		} else if strings.HasPrefix(word, "выходн") {
+			weekend = true
+		}
+	}
+	if len(clocks) == 2 {
+		quiet.From, quiet.Until = clocks[0], clocks[1]
+		parsed = true
+	} else if len(clocks) != 0 {
+		return entities.QuietHours{}, ErrParseQuiet
+	}
+	if !parsed || weekend {
+		return entities.QuietHours{}, ErrParseQuiet
+	}
+	return quiet, nil
+}
+
+// SetQuietHours saves the window when the chat gets no reminders
+func (t *TaskUsecase) SetQuietHours(ctx context.Context, chatID int64, message string) (entities.QuietHours, error) {
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return entities.QuietHours{}, errors.Join(ErrGetChat, err)
+	}
+	quiet, err := parseQuiet(message, chat.Quiet)
+	if err != nil {
+		return entities.QuietHours{}, err
+	}
+	err = t.cs.SetQuietHours(ctx, chatID, quiet)
+	if err != nil {
+		return entities.QuietHours{}, errors.Join(ErrSetQuiet, err)
+	}
+	return quiet, nil
+}
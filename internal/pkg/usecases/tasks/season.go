@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/pkg/regularity"
+)
+
+var allYearAnswers = map[string]bool{
+	"нет":         true,
+	"убрать":      true,
+	"круглый год": true,
+	"всегда":      true,
+}
+
+func (t *TaskUsecase) StartTaskSeasonEdit(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditSeason)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// editSeason takes the parts of the year the task is active in:
+// "с апреля по октябрь", "1 декабря - 15 января" or "круглый год" to remove the limit
+func (t *TaskUsecase) editSeason(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	season := []regularity.Window{}
+	if !allYearAnswers[answer(message)] {
+		var err error
+		season, err = regularity.ParseSeason(message)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseSeason, err)
+		}
+	}
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID: event.TaskID,
+		Season: &season,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditSeasonTaskResult(), nil
+}
@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+// lateGrace is how late a completion still counts as on time, reminders come once a day
+const lateGrace = 24 * time.Hour
+
+// taskStats walks the history of the task in order, a skip closes an occurrence
+// like a completion but is neither on time nor late
+func taskStats(task entities.UserTask, history []entities.HistoryRecord, snoozed int, now time.Time) entities.TaskStats {
+	stats := entities.TaskStats{Task: task, Snoozed: snoozed}
+	var previous time.Time
+	var delays time.Duration
+	streak := 0
+	for _, record := range history {
+		switch record.Kind {
+		case entities.HistorySkipped:
+			stats.Skipped++
+			previous = record.DoneAt
+			continue
+		case entities.HistoryCompleted:
+		default:
+			continue
+		}
+		stats.Completed++
+		if !previous.IsZero() && task.Regularity > 0 {
+			delay := record.DoneAt.Sub(previous) - task.Regularity
+			delays += delay
+			stats.Rated++
+			if delay <= lateGrace {
+				stats.OnTime++
+				streak++
+			} else {
+				streak = 0
+			}
+			stats.BestStreak = max(stats.BestStreak, streak)
+		}
+		previous = record.DoneAt
+	}
+	if stats.Rated > 0 {
+		stats.AvgDelay = delays / time.Duration(stats.Rated)
+	}
+	stats.CurrentStreak = streak
+	// an overdue task has already broken the streak
+	if !task.OneOff && !task.Paused() && task.NextDue().Add(lateGrace).Before(now) {
+		stats.CurrentStreak = 0
+	}
+	return stats
+}
+
+// computeStats makes statistics of the tasks, totals and member shares
+// also count done one-off and deleted tasks that are still in history
+func computeStats(tasks []entities.UserTask, history []entities.HistoryRecord, snoozes []entities.Snooze, now time.Time) entities.ChatStats {
+	stats := entities.ChatStats{Snoozed: len(snoozes)}
+	snoozedByTask := map[int64]int{}
+	for _, snooze := range snoozes {
+		snoozedByTask[snooze.TaskID]++
+	}
+	byTask := map[int64][]entities.HistoryRecord{}
+	members := map[int64]*entities.MemberStats{}
+	var order []int64
+	for _, record := range history {
+		byTask[record.TaskID] = append(byTask[record.TaskID], record)
+		switch record.Kind {
+		case entities.HistorySkipped:
+			stats.Skipped++
+		case entities.HistoryCompleted:
+			stats.Completed++
+			member, ok := members[record.UserID]
+			if !ok {
+				member = &entities.MemberStats{Member: entities.Member{ID: record.UserID}}
+				members[record.UserID] = member
+				order = append(order, record.UserID)
+			}
+			// the latest name wins
+			if record.UserName != "" {
+				member.Member.Name = record.UserName
+			}
+			member.Completed++
+		}
+	}
+	for _, task := range tasks {
+		stats.Tasks = append(stats.Tasks, taskStats(task, byTask[task.ID], snoozedByTask[task.ID], now))
+	}
+	for _, userID := range order {
+		member := *members[userID]
+		member.Share = float64(member.Completed) / float64(stats.Completed)
+		stats.Members = append(stats.Members, member)
+	}
+	sort.SliceStable(stats.Members, func(i, j int) bool {
+		return stats.Members[i].Completed > stats.Members[j].Completed
+	})
+	return stats
+}
+
+// GetStats computes statistics of the chat tasks from their history
+func (t *TaskUsecase) GetStats(ctx context.Context, chatID int64) (entities.ChatStats, error) {
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return entities.ChatStats{}, errors.Join(ErrGetTasks, err)
+	}
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return entities.ChatStats{}, errors.Join(ErrGetHistory, err)
+	}
+	snoozes, err := t.hs.GetChatSnoozes(ctx, chatID)
+	if err != nil {
+		return entities.ChatStats{}, errors.Join(ErrGetSnoozes, err)
+	}
+	return computeStats(tasks, history, snoozes, time.Now()), nil
+}
@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+)
+
+const (
+	// minSuggestionIntervals completions in a row are needed to tell the cadence
+	minSuggestionIntervals = 4
+	// maxSuggestionIntervals latest intervals are analysed
+	maxSuggestionIntervals = 6
+)
+
+// suggestRegularity offers the regularity the task is actually done with when most of
+// the latest intervals between completions are off by more than a day and a quarter of regularity,
+// intervals that are longer because of "remind later" count even if they are not consistent
+func suggestRegularity(task entities.UserTask, history []entities.HistoryRecord, snoozed int) (time.Duration, bool) {
+	if task.OneOff || task.CounterTask() || task.Regularity < 24*time.Hour {
+		return 0, false
+	}
+	var intervals []time.Duration
+	var previous time.Time
+	for _, record := range history {
+		switch record.Kind {
+		case entities.HistorySkipped:
+			// the interval over a skip is not a cadence
+			previous = time.Time{}
+		case entities.HistoryCompleted:
+			if !previous.IsZero() {
+				intervals = append(intervals, record.DoneAt.Sub(previous))
+			}
+			previous = record.DoneAt
+		}
+	}
+	if len(intervals) < minSuggestionIntervals {
+		return 0, false
+	}
+	intervals = intervals[max(0, len(intervals)-maxSuggestionIntervals):]
+	sorted := append([]time.Duration(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+	diff := median - task.Regularity
+	if diff.Abs() < max(task.Regularity/4, 24*time.Hour) {
+		return 0, false
+	}
+	sameSide := 0
+	for _, interval := range intervals {
+		if (diff > 0 && interval > task.Regularity+lateGrace) || (diff < 0 && interval < task.Regularity-lateGrace) {
+			sameSide++
+		}
+	}
+	consistent := sameSide*4 >= len(intervals)*3
+	if !consistent && !(diff > 0 && snoozed >= len(intervals)) {
+		return 0, false
+	}
+	days := (median + 12*time.Hour) / (24 * time.Hour)
+	return days * 24 * time.Hour, true
+}
+
+// updateSuggestion offers a new regularity if the task has no offer waiting for an answer,
+// completions before the previous offer are not analysed
+func (t *TaskUsecase) updateSuggestion(ctx context.Context, chatID int64, taskID int64) error {
+	task, err := t.ts.GetTask(ctx, taskID)
+	if err != nil {
+		return errors.Join(ErrGetTasks, err)
+	}
+	if task.SuggestedRegularity > 0 {
+		return nil
+	}
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return errors.Join(ErrGetHistory, err)
+	}
+	var taskHistory []entities.HistoryRecord
+	for _, record := range history {
+		if record.TaskID == taskID && !record.DoneAt.Before(task.SuggestedAt) {
+			taskHistory = append(taskHistory, record)
+		}
+	}
+	snoozes, err := t.hs.GetChatSnoozes(ctx, chatID)
+	if err != nil {
+		return errors.Join(ErrGetSnoozes, err)
+	}
+	snoozed := 0
+	for _, snooze := range snoozes {
+		if snooze.TaskID == taskID && !snooze.SnoozedAt.Before(task.SuggestedAt) {
+			snoozed++
+		}
+	}
+	suggested, ok := suggestRegularity(task, taskHistory, snoozed)
+	if !ok {
+		return nil
+	}
+	notSent := time.Time{}
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:              taskID,
+		SuggestedRegularity: &suggested,
+		SuggestedAt:         &notSent,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	return nil
+}
+
+func (t *TaskUsecase) MarkSuggestionSent(ctx context.Context, taskID int64, at time.Time) error {
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:      taskID,
+		SuggestedAt: &at,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	return nil
+}
+
+// AnswerSuggestion changes the regularity of the task to the offered one or keeps it,
+// either way the offer is closed, returns the updated task
+func (t *TaskUsecase) AnswerSuggestion(ctx context.Context, chatID int64, taskID int64, accept bool) (entities.UserTask, error) {
+	task, err := t.ts.GetTask(ctx, taskID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	if task.ChatID != chatID || task.SuggestedRegularity <= 0 || !task.DeletedAt.IsZero() {
+		return entities.UserTask{}, entities.ErrNoSuggestion
+	}
+	noSuggestion := time.Duration(0)
+	update := entities.TaskUpdate{
+		TaskID:              taskID,
+		SuggestedRegularity: &noSuggestion,
+	}
+	if accept {
+		update.Regularity = &task.SuggestedRegularity
+	}
+	err = t.ts.UpdateTask(ctx, update)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrUpdateTask, err)
+	}
+	task, err = t.ts.GetTask(ctx, taskID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	return task, nil
+}
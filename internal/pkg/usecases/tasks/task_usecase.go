@@ -2,9 +2,11 @@ package tasks
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"house-timer/internal/pkg/entities"
@@ -17,26 +19,29 @@ import (
 type TaskUsecase struct {
 	ts  entities.TaskStorage
 	tes entities.TaskEventStorage
+	hs  entities.HistoryStorage
+	cs  entities.ChatStorage
 }
 
 func NewTaskUsecase(
 	taskStorage entities.TaskStorage,
 	taskEventStorage entities.TaskEventStorage,
+	historyStorage entities.HistoryStorage,
+	chatStorage entities.ChatStorage,
 ) *TaskUsecase {
 	return &TaskUsecase{
 		ts:  taskStorage,
 		tes: taskEventStorage,
+		hs:  historyStorage,
+		cs:  chatStorage,
 	}
 }
 
 func (t *TaskUsecase) CreateEmptyTask(ctx context.Context, chatID int64) error {
-	log, err := logr.FromContext(ctx)
-	if err != nil {
-		return err
-	}
+	log := logr.FromContextOrDiscard(ctx)
 
 	// check if no current event
-	_, err = t.tes.GetCurrentTaskEvent(ctx, chatID)
+	_, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
 	if err == nil {
 		return ErrEventCollision
 	}
@@ -61,6 +66,37 @@ func (t *TaskUsecase) CreateEmptyTask(ctx context.Context, chatID int64) error {
 	return nil
 }
 
+// UndoDeleteWindow is the time the deletion can be undone from the deletion message
+const UndoDeleteWindow = 10 * time.Minute
+
+// minRegularityConfidence is the parse confidence below which the regularity is treated as a guess
+const minRegularityConfidence = 1.0
+
+var confirmations = map[string]bool{
+	"да":    true,
+	"ага":   true,
+	"угу":   true,
+	"верно": true,
+	"yes":   true,
+}
+
+func isConfirmation(message string) bool {
+	return confirmations[strings.Trim(strings.ToLower(message), " .!")]
+}
+
+func (t *TaskUsecase) finishCreation(ctx context.Context, event *entities.UserTaskEvent) (entities.TaskMessageResult, error) {
+	err := t.ts.FinishCreation(ctx, event.TaskID)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrFinishCreation, err)
+	}
+
+	err = t.tes.DeleteEvent(ctx, event.ID)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrDeleteEvent, err)
+	}
+	return entities.NewCreatedTask(), nil
+}
+
 func (t *TaskUsecase) handleCreateMessage(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
 	switch event.Step {
 	case entities.TaskCreationWaitName:
@@ -68,36 +104,57 @@ func (t *TaskUsecase) handleCreateMessage(ctx context.Context, event *entities.U
 		if err != nil {
 			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskName, err)
 		}
-		err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitRegularity)
+		// the category is optional and is chosen from the kind menu
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitKind)
 		if err != nil {
 			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
 		}
 		return entities.NewNameCreatedTaskResult(), nil
-	case entities.TaskCreationWaitRegularity:
-		reg, err := regularity.ExtractRegularity(message)
-		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseRegularity, err)
-		}
-		err = t.ts.CreateTaskRegularity(ctx, event.TaskID, reg)
+	case entities.TaskCreationWaitCategory:
+		err := t.setCategory(ctx, event.TaskID, message)
 		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskRegularity, err)
+			return entities.NewEmptyTaskMessageResult(), err
 		}
-
-		err = t.ts.FinishCreation(ctx, event.TaskID)
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitKind)
 		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrFinishCreation, err)
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
 		}
-
-		err = t.tes.DeleteEvent(ctx, event.ID)
-		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrDeleteEvent, err)
+		return entities.NewNeedTaskKindResult(), nil
+	case entities.TaskCreationWaitKind:
+		return t.handleKindMessage(ctx, event, chatID, message)
+	case entities.TaskCreationWaitDueDate:
+		return t.handleDueDateMessage(ctx, event, message)
+	case entities.TaskCreationWaitThreshold:
+		return t.handleThresholdMessage(ctx, event, chatID, message)
+	case entities.TaskCreationWaitRegularity, entities.TaskCreationConfirmRegularity:
+		if event.Step == entities.TaskCreationConfirmRegularity && isConfirmation(message) {
+			return t.finishCreation(ctx, event)
 		}
-		return entities.NewCreatedTask(), nil
+		return t.handleRegularityMessage(ctx, event, chatID, message)
 	default:
 		return entities.NewEmptyTaskMessageResult(), ErrUnknownTaskCreateStep
 	}
 }
 
+func (t *TaskUsecase) handleRegularityMessage(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	res, err := regularity.Parse(message)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseRegularity, err)
+	}
+	err = t.ts.CreateTaskRegularity(ctx, event.TaskID, res.Duration)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskRegularity, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationConfirmRegularity)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	if res.Confidence < minRegularityConfidence {
+		return entities.NewNeedRegularityConfirmResult(), nil
+	}
+	return entities.NewRegularityParsedResult(), nil
+}
+
 func (t *TaskUsecase) getOrderedTaskID(ctx context.Context, chatID int64, taskNum int64) (int64, error) {
 	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
 	if err != nil {
@@ -147,17 +204,31 @@ func (t *TaskUsecase) handleUpdateMessage(ctx context.Context, event *entities.U
 		}
 		return entities.NewGotEditNameTaskResult(), nil
 	case entities.TaskEditChangeRegularity:
-		event, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+		return t.editRegularity(ctx, event, chatID, message)
+	case entities.TaskEditDoneDate:
+		doneAt, err := regularity.ParsePastDate(message, time.Now())
 		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrGetCurrentTaskEvent, err)
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseDate, err)
+		}
+		err = t.completeTaskAt(ctx, chatID, doneByMember(event.Payload), event.TaskID, doneAt)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), err
+		}
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
 		}
-		reg, err := regularity.ExtractRegularity(message)
+		return entities.NewGotEditDoneTaskResult(), nil
+	case entities.TaskEditAnchorDate:
+		startAt, err := parseStartDate(message, time.Now())
 		if err != nil {
-			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseRegularity, err)
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseDate, err)
 		}
+		anchor := entities.AnchorFixed
 		err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
-			TaskID:     event.TaskID,
-			Regularity: &reg,
+			TaskID:  event.TaskID,
+			Anchor:  &anchor,
+			StartAt: &startAt,
 		})
 		if err != nil {
 			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
@@ -166,11 +237,62 @@ func (t *TaskUsecase) handleUpdateMessage(ctx context.Context, event *entities.U
 		if err != nil {
 			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
 		}
-		return entities.NewGotEditRegularityTaskResult(), nil
+		return entities.NewGotEditAnchorTaskResult(), nil
+	case entities.TaskEditDueDate:
+		return t.editDueDate(ctx, event, chatID, message)
+	case entities.TaskEditNotes:
+		return t.editNotes(ctx, event, message)
+	case entities.TaskEditDependency:
+		return t.editDependency(ctx, event, chatID, message)
+	case entities.TaskEditSeason:
+		return t.editSeason(ctx, event, chatID, message)
+	case entities.TaskEditPoints:
+		return t.editPoints(ctx, event, chatID, message)
+	case entities.TaskEditChecklist:
+		return t.editChecklist(ctx, event, chatID, message)
+	case entities.TaskEditCategory:
+		err := t.setCategory(ctx, event.TaskID, message)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), err
+		}
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+		if err != nil {
+			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.NewGotEditCategoryTaskResult(), nil
 	}
 	return entities.NewEmptyTaskMessageResult(), ErrUnknownTaskEditStep
 }
 
+// editRegularity saves the new regularity, a guessed one waits for "да" like at creation
+func (t *TaskUsecase) editRegularity(ctx context.Context, event *entities.UserTaskEvent, chatID int64, message string) (entities.TaskMessageResult, error) {
+	if confirmsGuess(event, message) {
+		return t.acceptGuess(ctx, event, chatID, entities.NewGotEditRegularityTaskResult())
+	}
+	reg, guessed, err := t.parseGuarded(ctx, event, message)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrParseRegularity, err)
+	}
+	// a one-off task given a regularity becomes recurring, its date stays as the deadline
+	oneOff := false
+	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:     event.TaskID,
+		Regularity: &reg,
+		OneOff:     &oneOff,
+	})
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTask, err)
+	}
+	if guessed {
+		return entities.NewNeedRegularityConfirmResult(), nil
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return entities.NewEmptyTaskMessageResult(), errors.Join(ErrUpdateTaskStep, err)
+	}
+	return entities.NewGotEditRegularityTaskResult(), nil
+}
+
 func (t *TaskUsecase) HandleTaskMessage(ctx context.Context, chatID int64, message string) (entities.TaskMessageResult, error) {
 	event, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
 	if err != nil {
@@ -255,7 +377,7 @@ func (t *TaskUsecase) StopTaskEdit(ctx context.Context, chatID int64) error {
 	if err != nil {
 		return err
 	}
-	if (currentEvent.Step != entities.TaskEditGetNumber) && (currentEvent.Step != entities.TaskEditWait) {
+	if !currentEvent.Step.EditStoppable() {
 		return ErrBadTaskEvent
 	}
 	err = t.tes.DeleteEvent(ctx, currentEvent.ID)
@@ -280,14 +402,11 @@ func (t *TaskUsecase) ResetTaskEdit(ctx context.Context, chatID int64) error {
 	return nil
 }
 
-func (t *TaskUsecase) RemindLater(ctx context.Context, chatID int64) error {
-	taskEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+func (t *TaskUsecase) RemindLater(ctx context.Context, chatID int64, member entities.Member) error {
+	taskEvent, err := t.getRemindEvent(ctx, chatID)
 	if err != nil {
 		return err
 	}
-	if taskEvent.Step != entities.TaskRemindWait || taskEvent.Type != entities.TaskRemindEvent {
-		return ErrBadTaskEvent
-	}
 	remindDur := time.Hour * 24
 	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
 		TaskID:      taskEvent.TaskID,
@@ -296,6 +415,16 @@ func (t *TaskUsecase) RemindLater(ctx context.Context, chatID int64) error {
 	if err != nil {
 		return errors.Join(ErrUpdateTask, err)
 	}
+	_, err = t.hs.AddSnooze(ctx, entities.Snooze{
+		ChatID:    chatID,
+		TaskID:    taskEvent.TaskID,
+		SnoozedAt: time.Now(),
+		UserID:    member.ID,
+		UserName:  member.Name,
+	})
+	if err != nil {
+		return errors.Join(ErrAddSnooze, err)
+	}
 	err = t.tes.DeleteEvent(ctx, taskEvent.ID)
 	if err != nil {
 		return err
@@ -303,59 +432,457 @@ func (t *TaskUsecase) RemindLater(ctx context.Context, chatID int64) error {
 	return nil
 }
 
-func (t *TaskUsecase) CompleteTask(ctx context.Context, chatID int64) error {
+// markTask moves the schedule of the task to lastReminded and adds record to history
+// on behalf of the member, a completion awards points to the member
+func (t *TaskUsecase) markTask(ctx context.Context, member entities.Member, record entities.HistoryRecord, lastReminded time.Time) error {
+	record.UserID, record.UserName = member.ID, member.Name
+	if record.Kind == entities.HistoryCompleted {
+		task, err := t.ts.GetTask(ctx, record.TaskID)
+		if err != nil {
+			return errors.Join(ErrGetTasks, err)
+		}
+		record.Points = awardPoints(task, record.DoneAt)
+	}
+	remindAfter := time.Duration(0)
+	err := t.ts.UpdateTask(ctx, entities.TaskUpdate{
+		TaskID:       record.TaskID,
+		LastReminded: &lastReminded,
+		RemindAfter:  &remindAfter,
+	})
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	_, err = t.hs.AddRecord(ctx, record)
+	if err != nil {
+		return errors.Join(ErrAddHistory, err)
+	}
+	err = t.finishOccurrence(ctx, record.TaskID, record.Kind)
+	if err != nil {
+		return err
+	}
+	// the completion is already recorded, failures of what follows it must not fail the completion
+	if record.Kind == entities.HistoryCompleted {
+		log := logr.FromContextOrDiscard(ctx)
+		err = t.scheduleDependents(ctx, record.ChatID, record.TaskID, record.DoneAt)
+		if err != nil {
+			log.Error(err, "failed to schedule dependent tasks", "taskID", record.TaskID)
+		}
+		err = t.updateSuggestion(ctx, record.ChatID, record.TaskID)
+		if err != nil {
+			log.Error(err, "failed to update regularity suggestion", "taskID", record.TaskID)
+		}
+	}
+	return nil
+}
+
+// closeRemind marks the reminded task and finishes the remind event
+func (t *TaskUsecase) closeRemind(ctx context.Context, chatID int64, member entities.Member, taskEvent entities.UserTaskEvent, lastReminded time.Time, kind entities.HistoryKind) error {
+	err := t.markTask(ctx, member, entities.HistoryRecord{
+		ChatID: chatID,
+		TaskID: taskEvent.TaskID,
+		Kind:   kind,
+		DoneAt: time.Now(),
+	}, lastReminded)
+	if err != nil {
+		return err
+	}
+	err = t.tes.DeleteEvent(ctx, taskEvent.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// completeTaskAt marks the task done at the moment, a pending reminder of it is closed
+func (t *TaskUsecase) completeTaskAt(ctx context.Context, chatID int64, member entities.Member, taskID int64, at time.Time) error {
+	err := t.markTask(ctx, member, entities.HistoryRecord{
+		ChatID: chatID,
+		TaskID: taskID,
+		Kind:   entities.HistoryCompleted,
+		DoneAt: at,
+	}, at)
+	if err != nil {
+		return err
+	}
 	taskEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
+		return nil
+	}
+	if err != nil {
+		return errors.Join(ErrGetCurrentTaskEvent, err)
+	}
+	if taskEvent.Type == entities.TaskRemindEvent && taskEvent.TaskID == taskID {
+		err = t.tes.DeleteEvent(ctx, taskEvent.ID)
+		if err != nil {
+			return errors.Join(ErrDeleteEvent, err)
+		}
+	}
+	return nil
+}
+
+func (t *TaskUsecase) getRemindEvent(ctx context.Context, chatID int64) (entities.UserTaskEvent, error) {
+	taskEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.UserTaskEvent{}, err
+	}
+	if taskEvent.Type != entities.TaskRemindEvent ||
+		(taskEvent.Step != entities.TaskRemindWait && taskEvent.Step != entities.TaskRemindWaitProof) {
+		return entities.UserTaskEvent{}, ErrBadTaskEvent
+	}
+	return taskEvent, nil
+}
+
+// CompleteTask completes the reminded task, tasks requiring proof
+// wait for a photo given to CompleteTaskWithProof and entities.ErrProofRequired is returned
+func (t *TaskUsecase) CompleteTask(ctx context.Context, chatID int64, member entities.Member) error {
+	taskEvent, err := t.getRemindEvent(ctx, chatID)
 	if err != nil {
 		return err
 	}
-	if taskEvent.Step != entities.TaskRemindWait || taskEvent.Type != entities.TaskRemindEvent {
-		return ErrBadTaskEvent
+	task, err := t.ts.GetTask(ctx, taskEvent.TaskID)
+	if err != nil {
+		return errors.Join(ErrGetTasks, err)
+	}
+	if task.RequiresProof {
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskRemindWaitProof)
+		if err != nil {
+			return errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.ErrProofRequired
+	}
+	return t.closeRemind(ctx, chatID, member, taskEvent, time.Now(), entities.HistoryCompleted)
+}
+
+// nextOccurrence returns LastReminded for the task to be reminded on the first
+// scheduled day after now, as if the due occurrences were done on time,
+// the deadline, the reached counter and the chained date are not part of the schedule
+// and would hold a passed day forever
+func nextOccurrence(task entities.UserTask, now time.Time) time.Time {
+	task.RemindAfter = 0
+	task.DueAt = time.Time{}
+	task.CounterReachedAt = time.Time{}
+	task.ChainedAt = time.Time{}
+	task.LastReminded = task.LastReminded.Add(task.Regularity)
+	if late := now.Sub(task.NextRemind()); late >= 0 {
+		// whole missed periods are skipped at once, the loop below only fixes rounding to days
+		task.LastReminded = task.LastReminded.Add(late / task.Regularity * task.Regularity)
+	}
+	for !task.NextRemind().After(now) {
+		task.LastReminded = task.LastReminded.Add(task.Regularity)
+	}
+	return task.LastReminded
+}
+
+// SkipTask moves the reminded task to the next occurrence without completing it,
+// returns when it is going to be reminded or zero time for one-off tasks
+func (t *TaskUsecase) SkipTask(ctx context.Context, chatID int64, member entities.Member) (time.Time, error) {
+	taskEvent, err := t.getRemindEvent(ctx, chatID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	task, err := t.ts.GetTask(ctx, taskEvent.TaskID)
+	if err != nil {
+		return time.Time{}, errors.Join(ErrGetTasks, err)
+	}
+	if task.OneOff {
+		// there is no next occurrence, skipped one-off task is archived
+		return time.Time{}, t.closeRemind(ctx, chatID, member, taskEvent, time.Now(), entities.HistorySkipped)
+	}
+	if task.Regularity <= 0 {
+		return time.Time{}, entities.ErrNoSchedule
 	}
 	now := time.Now()
-	remindAfter := time.Duration(0)
+	// a passed deadline goes with the skipped occurrence, a deadline still ahead is kept
+	if !task.DueAt.IsZero() && !task.DueAt.After(now) {
+		task.DueAt = time.Time{}
+		err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
+			TaskID: task.ID,
+			DueAt:  &task.DueAt,
+		})
+		if err != nil {
+			return time.Time{}, errors.Join(ErrUpdateTask, err)
+		}
+	}
+	task.LastReminded = nextOccurrence(task, now)
+	task.RemindAfter = 0
+	// the reached counter waits for the next reading after the skip
+	// and the chained date goes with the skipped occurrence, see finishOccurrence
+	task.CounterReachedAt = time.Time{}
+	task.ChainedAt = time.Time{}
+	err = t.closeRemind(ctx, chatID, member, taskEvent, task.LastReminded, entities.HistorySkipped)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return task.NextRemind(), nil
+}
+
+// doneByPayload keeps the member marking the edited task done until the date is given
+func doneByPayload(member entities.Member) string {
+	return strconv.FormatInt(member.ID, 10) + " " + member.Name
+}
+
+func doneByMember(payload string) entities.Member {
+	id, name, _ := strings.Cut(payload, " ")
+	userID, _ := strconv.ParseInt(id, 10, 64)
+	return entities.Member{ID: userID, Name: name}
+}
+
+// StartTaskDone asks when the edited task was done by the member
+func (t *TaskUsecase) StartTaskDone(ctx context.Context, chatID int64, member entities.Member) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	task, err := t.ts.GetTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return errors.Join(ErrGetTasks, err)
+	}
+	if task.RequiresProof {
+		return entities.ErrProofRequired
+	}
+	err = t.tes.SetPayload(ctx, currentEvent.ID, doneByPayload(member))
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditDoneDate)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// parseStartDate reads the first day of a fixed schedule, past ("вчера", "01.10") or future ("25.10")
+func parseStartDate(message string, now time.Time) (time.Time, error) {
+	startAt, err := regularity.ParsePastDate(message, now)
+	if err != nil {
+		var futureErr error
+		startAt, futureErr = regularity.ParseFutureDate(message, now)
+		if futureErr != nil {
+			return time.Time{}, errors.Join(err, futureErr)
+		}
+	}
+	return startAt.Truncate(24 * time.Hour), nil
+}
+
+// ToggleTaskAnchor switches the edited task with fixed schedule back to counting from completion,
+// other tasks are switched to fixed schedule once the start date is given,
+// returns the mode being switched to
+func (t *TaskUsecase) ToggleTaskAnchor(ctx context.Context, chatID int64) (entities.AnchorMode, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return "", ErrBadTaskEvent
+	}
+	task, err := t.ts.GetTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return "", errors.Join(ErrGetTasks, err)
+	}
+	if task.OneOff {
+		return "", ErrOneOffTask
+	}
+	if task.Anchor != entities.AnchorFixed {
+		err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditAnchorDate)
+		if err != nil {
+			return "", errors.Join(ErrUpdateTaskStep, err)
+		}
+		return entities.AnchorFixed, nil
+	}
+	anchor := entities.AnchorCompletion
 	err = t.ts.UpdateTask(ctx, entities.TaskUpdate{
-		TaskID:       taskEvent.TaskID,
-		LastReminded: &now,
-		RemindAfter:  &remindAfter,
+		TaskID: task.ID,
+		Anchor: &anchor,
 	})
 	if err != nil {
-		return errors.Join(ErrUpdateTask, err)
+		return "", errors.Join(ErrUpdateTask, err)
 	}
-	err = t.tes.DeleteEvent(ctx, taskEvent.ID)
+	return anchor, nil
+}
+
+// findTask resolves the task by its number or name at the start of message,
+// returns the task and the rest of message
+func (t *TaskUsecase) findTask(ctx context.Context, chatID int64, message string) (entities.UserTask, string, error) {
+	words := strings.Fields(message)
+	if len(words) == 0 {
+		return entities.UserTask{}, "", ErrUnknownTask
+	}
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return entities.UserTask{}, "", errors.Join(ErrGetTasks, err)
+	}
+	if num, err := strconv.Atoi(words[0]); err == nil {
+		if num <= 0 || num > len(tasks) {
+			return entities.UserTask{}, "", errors.Join(ErrBadTaskNumber, fmt.Errorf("wtf task number %d", num))
+		}
+		return tasks[num-1], strings.Join(words[1:], " "), nil
+	}
+	// the longest matching name wins, "полить цветы" over "полить"
+	for n := len(words); n > 0; n-- {
+		name := entities.NormalizeName(strings.Join(words[:n], " "))
+		for _, task := range tasks {
+			if entities.NormalizeName(task.Name) == name {
+				return task, strings.Join(words[n:], " "), nil
+			}
+		}
+	}
+	return entities.UserTask{}, "", ErrUnknownTask
+}
+
+// DoneTask marks the task done by "<номер или название> [когда]", e.g. "2 вчера",
+// the moment defaults to now, returns the task and the moment
+func (t *TaskUsecase) DoneTask(ctx context.Context, chatID int64, member entities.Member, message string) (entities.UserTask, time.Time, error) {
+	task, rest, err := t.findTask(ctx, chatID, message)
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, err
+	}
+	now := time.Now()
+	doneAt := now
+	if rest != "" {
+		doneAt, err = regularity.ParsePastDate(rest, now)
+		if err != nil {
+			return entities.UserTask{}, time.Time{}, errors.Join(ErrParseDate, err)
+		}
+	}
+	if task.RequiresProof {
+		return entities.UserTask{}, time.Time{}, entities.ErrProofRequired
+	}
+	err = t.completeTaskAt(ctx, chatID, member, task.ID, doneAt)
+	if err != nil {
+		return entities.UserTask{}, time.Time{}, err
+	}
+	task.LastReminded = doneAt
+	task.RemindAfter = 0
+	return task, doneAt, nil
+}
+
+// StartTaskDelete asks for confirmation before deleting the edited task
+func (t *TaskUsecase) StartTaskDelete(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
 	if err != nil {
 		return err
 	}
+	if currentEvent.Step != entities.TaskEditWait {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditConfirmDelete)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
 	return nil
 }
 
-// DeleteCurrentTask
-func (t *TaskUsecase) DeleteCurrentTask(ctx context.Context, chatID int64) error {
+func (t *TaskUsecase) CancelTaskDelete(ctx context.Context, chatID int64) error {
 	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
 	if err != nil {
 		return err
 	}
-	if currentEvent.Step != entities.TaskEditWait {
+	if currentEvent.Step != entities.TaskEditConfirmDelete {
 		return ErrBadTaskEvent
 	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
+
+// DeleteCurrentTask moves confirmed task to trash and finishes editing, returns the task id
+func (t *TaskUsecase) DeleteCurrentTask(ctx context.Context, chatID int64) (int64, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+	if currentEvent.Step != entities.TaskEditConfirmDelete {
+		return 0, ErrBadTaskEvent
+	}
 	err = t.ts.DeleteTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	err = t.tes.DeleteEvent(ctx, currentEvent.ID)
+	if err != nil {
+		return 0, errors.Join(ErrDeleteEvent, err)
+	}
+	return currentEvent.TaskID, nil
+}
+
+func (t *TaskUsecase) getDeletedTask(ctx context.Context, chatID int64, taskID int64) (entities.UserTask, error) {
+	task, err := t.ts.GetTask(ctx, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// already purged
+		return entities.UserTask{}, ErrNotInTrash
+	}
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	if task.ChatID != chatID || task.DeletedAt.IsZero() {
+		return entities.UserTask{}, ErrNotInTrash
+	}
+	return task, nil
+}
+
+// UndoDeleteTask restores the task if it was deleted less than UndoDeleteWindow ago
+func (t *TaskUsecase) UndoDeleteTask(ctx context.Context, chatID int64, taskID int64) error {
+	task, err := t.getDeletedTask(ctx, chatID, taskID)
 	if err != nil {
 		return err
 	}
-	err = t.tes.UpdateStep(ctx, chatID, entities.TaskEditWait)
+	if time.Since(task.DeletedAt) > UndoDeleteWindow {
+		return ErrUndoExpired
+	}
+	err = t.ts.RestoreTask(ctx, taskID)
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
+	return nil
+}
+
+func (t *TaskUsecase) GetDeletedTasks(ctx context.Context, chatID int64) ([]entities.UserTask, error) {
+	tasks, err := t.ts.GetDeletedTasksForChat(ctx, chatID)
+	if err != nil {
+		return nil, errors.Join(ErrGetTasks, err)
+	}
+	return tasks, nil
+}
+
+// RestoreTask returns the task from trash
+func (t *TaskUsecase) RestoreTask(ctx context.Context, chatID int64, taskID int64) error {
+	_, err := t.getDeletedTask(ctx, chatID, taskID)
 	if err != nil {
 		return err
 	}
+	err = t.ts.RestoreTask(ctx, taskID)
+	if err != nil {
+		return errors.Join(ErrUpdateTask, err)
+	}
 	return nil
 }
 
-func (t *TaskUsecase) HandleRemind(ctx context.Context, chatID int64) (entities.TaskMessageResult, error) {
+// PurgeTrash removes tasks deleted before the time for good
+func (t *TaskUsecase) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := t.ts.PurgeDeletedTasks(ctx, before)
+	if err != nil {
+		return 0, errors.Join(ErrPurgeTrash, err)
+	}
+	return purged, nil
+}
+
+func (t *TaskUsecase) HandleRemind(ctx context.Context, chatID int64, taskID int64) (entities.TaskMessageResult, error) {
 	_, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
 	if err != nil {
 		if errors.Is(err, sqlite_repo.ErrNoTaskEvent) {
-			_, err = t.tes.CreateTaskEvent(ctx, chatID, entities.TaskRemindEvent, entities.TaskRemindWait)
+			eventID, err := t.tes.CreateTaskEvent(ctx, chatID, entities.TaskRemindEvent, entities.TaskRemindWait)
 			if err != nil {
 				return entities.NewEmptyTaskMessageResult(), errors.Join(ErrCreateTaskEvent, err)
 			}
+			err = t.tes.AddTaskID(ctx, eventID, taskID)
+			if err != nil {
+				return entities.NewEmptyTaskMessageResult(), errors.Join(ErrAddTaskID, err)
+			}
 			return entities.NewNeedRemindMessageResult(), nil
 		} else {
 			return entities.NewEmptyTaskMessageResult(), errors.Join(ErrGetCurrentTaskEvent, err)
@@ -369,7 +896,13 @@ func (t *TaskUsecase) StopTaskCreation(ctx context.Context, chatID int64) error
 	if err != nil {
 		return err
 	}
-	if currentEvent.Step != entities.TaskCreationWaitName && currentEvent.Step != entities.TaskCreationWaitRegularity {
+	if currentEvent.Step != entities.TaskCreationWaitName &&
+		currentEvent.Step != entities.TaskCreationWaitCategory &&
+		currentEvent.Step != entities.TaskCreationWaitKind &&
+		currentEvent.Step != entities.TaskCreationWaitDueDate &&
+		currentEvent.Step != entities.TaskCreationWaitThreshold &&
+		currentEvent.Step != entities.TaskCreationWaitRegularity &&
+		currentEvent.Step != entities.TaskCreationConfirmRegularity {
 		return ErrBadTaskEvent
 	}
 	err = t.tes.DeleteEvent(ctx, currentEvent.ID)
@@ -382,3 +915,48 @@ func (t *TaskUsecase) StopTaskCreation(ctx context.Context, chatID int64) error
 	}
 	return nil
 }
+
+// CurrentTask returns the task of the current chat event
+func (t *TaskUsecase) CurrentTask(ctx context.Context, chatID int64) (entities.UserTask, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.UserTask{}, err
+	}
+	if currentEvent.TaskID == 0 {
+		return entities.UserTask{}, ErrBadTaskEvent
+	}
+	task, err := t.ts.GetTask(ctx, currentEvent.TaskID)
+	if err != nil {
+		return entities.UserTask{}, errors.Join(ErrGetTasks, err)
+	}
+	return task, nil
+}
+
+// ConfirmTaskRegularity finishes creation with the parsed regularity
+func (t *TaskUsecase) ConfirmTaskRegularity(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationConfirmRegularity {
+		return ErrBadTaskEvent
+	}
+	_, err = t.finishCreation(ctx, &currentEvent)
+	return err
+}
+
+// RejectTaskRegularity asks for the regularity again
+func (t *TaskUsecase) RejectTaskRegularity(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Step != entities.TaskCreationConfirmRegularity {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskCreationWaitRegularity)
+	if err != nil {
+		return errors.Join(ErrUpdateTaskStep, err)
+	}
+	return nil
+}
@@ -1,15 +1,21 @@
 package tasks
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"embed"
 	"github.com/stretchr/testify/require"
+	"image/png"
 	"math/rand"
 	"testing"
 	"time"
 
+	"house-timer/internal/pkg/entities"
 	"house-timer/internal/pkg/repos/sqlite_repo"
+	"house-timer/internal/pkg/transfer"
+	"house-timer/pkg/chart"
+	"house-timer/pkg/regularity"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pressly/goose/v3"
@@ -38,6 +44,25 @@ func setupTestDB(t *testing.T) *sql.DB {
 	return db
 }
 
+// testStorages are the storages behind the usecase made by setupTestUsecase
+type testStorages struct {
+	tasks   *sqlite_repo.SqliteTaskStorage
+	events  *sqlite_repo.SqliteTaskEventStorage
+	history *sqlite_repo.SqliteHistoryStorage
+	chats   *sqlite_repo.SqliteChatStorage
+}
+
+func setupTestUsecase(t *testing.T) (*TaskUsecase, testStorages) {
+	db := setupTestDB(t)
+	storages := testStorages{
+		tasks:   sqlite_repo.NewSqliteTaskStorage(db),
+		events:  sqlite_repo.NewSqliteTaskEventStorage(db),
+		history: sqlite_repo.NewSqliteHistoryStorage(db),
+		chats:   sqlite_repo.NewSqliteChatStorage(db),
+	}
+	return NewTaskUsecase(storages.tasks, storages.events, storages.history, storages.chats), storages
+}
+
 func generateChatID() int64 {
 	return rand.Int63()
 }
@@ -46,7 +71,9 @@ func TestTaskUsecase(t *testing.T) {
 	db := setupTestDB(t)
 	taskEventStorage := sqlite_repo.NewSqliteTaskEventStorage(db)
 	taskStorage := sqlite_repo.NewSqliteTaskStorage(db)
-	taskUsecase := NewTaskUsecase(taskStorage, taskEventStorage)
+	historyStorage := sqlite_repo.NewSqliteHistoryStorage(db)
+	chatStorage := sqlite_repo.NewSqliteChatStorage(db)
+	taskUsecase := NewTaskUsecase(taskStorage, taskEventStorage, historyStorage, chatStorage)
 
 	chatID := generateChatID()
 	ctx := context.Background()
@@ -60,7 +87,10 @@ func TestTaskUsecase(t *testing.T) {
 
 	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "2 дня")
 	require.NoError(t, err)
-	require.True(t, res.IsTaskCreated())
+	require.True(t, res.IsRegularityParsed())
+
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
 
 	tasks, err := taskStorage.GetTasksForChat(ctx, chatID)
 	require.NoError(t, err)
@@ -78,7 +108,10 @@ func TestTaskUsecase(t *testing.T) {
 
 	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "10 месяцев")
 	require.NoError(t, err)
-	require.True(t, res.IsTaskCreated())
+	require.True(t, res.IsRegularityParsed())
+
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
 
 	tasks, err = taskStorage.GetTasksForChat(ctx, chatID)
 	require.NoError(t, err)
@@ -120,3 +153,1588 @@ func TestTaskUsecase(t *testing.T) {
 	require.Len(t, tasks, 2)
 	require.Equal(t, tasks[1].Name, "Новое имя 2")
 }
+
+func TestTaskCreationRegularityConfirm(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	chatID := generateChatID()
+	ctx := context.Background()
+	err := taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "Полить цветы")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskNameCreated())
+
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "14")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRegularityConfirm())
+
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour*24*14, task.Regularity)
+
+	err = taskUsecase.RejectTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
+
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "2 нидели")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRegularityConfirm())
+
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "да")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskCreated())
+
+	tasks, err := storages.tasks.GetTasksForChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, time.Hour*24*14, tasks[0].Regularity)
+
+	err = taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Помыть окна")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskNameCreated())
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "3 банана")
+	require.ErrorIs(t, err, ErrParseRegularity)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "раз в месяц")
+	require.NoError(t, err)
+	require.True(t, res.IsRegularityParsed())
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Да!")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskCreated())
+}
+
+func TestTaskEditRegularityConfirm(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "неделя")
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	err = taskUsecase.StartTaskRegularityEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	// "да" without a guess is not a regularity
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "да")
+	require.ErrorIs(t, err, ErrParseRegularity)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "10")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRegularityConfirm())
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "2 нидели")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRegularityConfirm())
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour*24*14, task.Regularity)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "да")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditRegularityTaskResult())
+
+	err = taskUsecase.StartTaskRegularityEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "каждые 3 дня")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditRegularityTaskResult())
+
+	err = taskUsecase.StartTaskDueDateEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "через 2 нидели")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedDueDateConfirm())
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Да")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditDueDateTaskResult())
+	task, err = taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour*24*3, task.Regularity)
+	require.WithinDuration(t, time.Now().Add(time.Hour*24*14), task.DueAt, time.Minute)
+}
+
+func createTestTask(t *testing.T, taskUsecase *TaskUsecase, chatID int64, name string, regularity string) {
+	ctx := context.Background()
+	err := taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, name)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, regularity)
+	require.NoError(t, err)
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
+}
+
+func TestExportImport(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	fromChatID := generateChatID()
+	createTestTask(t, taskUsecase, fromChatID, "Полить цветы", "1 неделя")
+	createTestTask(t, taskUsecase, fromChatID, "Поменять фильтр", "3 месяца")
+
+	tasks, err := taskUsecase.GetTasks(ctx, fromChatID)
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleRemind(ctx, fromChatID, tasks[0].ID)
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRemindMessageResult())
+	err = taskUsecase.CompleteTask(ctx, fromChatID, entities.Member{})
+	require.NoError(t, err)
+
+	// schedule, proof, checklist, pause and dependency of tasks and the chat settings are moved too
+	now := time.Now().Truncate(time.Second)
+	fixed := entities.AnchorFixed
+	startAt := now.Add(-30 * 24 * time.Hour)
+	pausedAt := now.Add(-time.Hour)
+	requiresProof := true
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: tasks[0].ID, Anchor: &fixed, StartAt: &startAt,
+		PausedAt: &pausedAt, RequiresProof: &requiresProof})
+	require.NoError(t, err)
+	err = storages.tasks.SetChecklist(ctx, tasks[0].ID, []string{"Налить воды", "Протереть листья"})
+	require.NoError(t, err)
+	afterTaskID := tasks[0].ID
+	afterDelay := 48 * time.Hour
+	chainedAt := now.Add(24 * time.Hour)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: tasks[1].ID, AfterTaskID: &afterTaskID,
+		AfterDelay: &afterDelay, ChainedAt: &chainedAt})
+	require.NoError(t, err)
+	err = storages.chats.SetVacation(ctx, fromChatID, now, now.Add(7*24*time.Hour))
+	require.NoError(t, err)
+	digest := entities.DigestSettings{Period: entities.DigestWeekly, Hour: 9, Weekday: time.Monday, Only: true}
+	err = storages.chats.SetDigest(ctx, fromChatID, digest)
+	require.NoError(t, err)
+	quiet := entities.QuietHours{From: 22 * time.Hour, Until: 8 * time.Hour, WeekendUntil: 10 * time.Hour, Offset: 3 * time.Hour}
+	err = storages.chats.SetQuietHours(ctx, fromChatID, quiet)
+	require.NoError(t, err)
+
+	export, err := taskUsecase.ExportChat(ctx, fromChatID)
+	require.NoError(t, err)
+	require.Len(t, export.Tasks, 2)
+	require.Len(t, export.History, 1)
+	require.Equal(t, tasks[0].ID, export.History[0].TaskID)
+
+	toChatID := generateChatID()
+	createTestTask(t, taskUsecase, toChatID, "полить цветы ", "2 недели")
+
+	err = taskUsecase.StartImport(ctx, toChatID)
+	require.NoError(t, err)
+	diff, err := taskUsecase.PreviewImport(ctx, toChatID, "file", export)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Поменять фильтр"}, diff.Created)
+	require.Equal(t, []string{"полить цветы "}, diff.Updated)
+	require.Equal(t, 1, diff.NewHistory)
+
+	ref, err := taskUsecase.ImportFileRef(ctx, toChatID)
+	require.NoError(t, err)
+	require.Equal(t, "file", ref)
+
+	_, err = taskUsecase.ApplyImport(ctx, toChatID, export)
+	require.NoError(t, err)
+
+	imported, err := taskUsecase.GetTasks(ctx, toChatID)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+	require.Equal(t, time.Hour*24*7, imported[0].Regularity)
+	require.Equal(t, "Поменять фильтр", imported[1].Name)
+	require.Equal(t, time.Hour*24*90, imported[1].Regularity)
+	history, err := storages.history.GetChatHistory(ctx, toChatID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, imported[0].ID, history[0].TaskID)
+
+	require.Equal(t, entities.AnchorFixed, imported[0].Anchor)
+	require.True(t, startAt.Equal(imported[0].StartAt))
+	require.True(t, pausedAt.Equal(imported[0].PausedAt))
+	require.True(t, imported[0].RequiresProof)
+	checklist, err := storages.tasks.GetChecklist(ctx, imported[0].ID)
+	require.NoError(t, err)
+	require.Len(t, checklist, 2)
+	require.Equal(t, "Протереть листья", checklist[1].Text)
+	require.Equal(t, imported[0].ID, imported[1].AfterTaskID)
+	require.Equal(t, afterDelay, imported[1].AfterDelay)
+	require.True(t, chainedAt.Equal(imported[1].ChainedAt))
+	require.False(t, imported[1].Paused())
+	chat, err := storages.chats.GetChat(ctx, toChatID)
+	require.NoError(t, err)
+	require.True(t, now.Equal(chat.VacationFrom))
+	require.True(t, now.Add(7*24*time.Hour).Equal(chat.VacationUntil))
+	require.Equal(t, digest, chat.Digest)
+	require.Equal(t, quiet, chat.Quiet)
+
+	err = taskUsecase.StartImport(ctx, toChatID)
+	require.NoError(t, err)
+	diff, err = taskUsecase.PreviewImport(ctx, toChatID, "file", export)
+	require.NoError(t, err)
+	require.Empty(t, diff.Created)
+	require.Empty(t, diff.Updated)
+	require.Len(t, diff.Unchanged, 2)
+	require.Zero(t, diff.NewHistory)
+	err = taskUsecase.StopImport(ctx, toChatID)
+	require.NoError(t, err)
+
+	broken := map[string]func(export *entities.ChatExport){
+		"no regularity": func(export *entities.ChatExport) { export.Tasks[0].RegularitySeconds = 0 },
+		"bad season": func(export *entities.ChatExport) {
+			season := "13-40:02-31"
+			export.Tasks[0].Season = &season
+		},
+		"many points": func(export *entities.ChatExport) {
+			points := 500
+			export.Tasks[0].Points = &points
+		},
+		"tag with space": func(export *entities.ChatExport) {
+			export.Tasks[0].Tags = &[]string{"две метки"}
+		},
+		"fixed schedule without start": func(export *entities.ChatExport) { export.Tasks[0].AnchorAt = nil },
+		"empty checklist item": func(export *entities.ChatExport) {
+			export.Tasks[0].Checklist = &[]string{"Налить воды", ""}
+		},
+		"unknown task followed": func(export *entities.ChatExport) {
+			after := "помыть слона"
+			export.Tasks[1].After = &after
+		},
+		"dependency cycle": func(export *entities.ChatExport) {
+			after := export.Tasks[1].Name
+			export.Tasks[0].After = &after
+		},
+		"digest hour": func(export *entities.ChatExport) { export.Settings.Digest.Hour = 24 },
+		"time zone": func(export *entities.ChatExport) {
+			export.Settings.Quiet.OffsetSeconds = int64((15 * time.Hour).Seconds())
+		},
+		"vacation ends before start": func(export *entities.ChatExport) {
+			export.Settings.Vacation.Until = export.Settings.Vacation.From.Add(-time.Hour)
+		},
+		"unknown kind": func(export *entities.ChatExport) { export.History[0].Kind = "done" },
+		"bad token":    func(export *entities.ChatExport) { export.Settings.ICalToken = "secret" },
+	}
+	for name, breakExport := range broken {
+		t.Run(name, func(t *testing.T) {
+			export, err := taskUsecase.ExportChat(ctx, fromChatID)
+			require.NoError(t, err)
+			breakExport(&export)
+			err = taskUsecase.StartImport(ctx, toChatID)
+			require.NoError(t, err)
+			_, err = taskUsecase.PreviewImport(ctx, toChatID, "file", export)
+			require.ErrorIs(t, err, ErrInvalidImport)
+			err = taskUsecase.StopImport(ctx, toChatID)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTrash(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Разморозить морозилку", "3 месяца")
+
+	deleteTask := func() int64 {
+		err := taskUsecase.StartTaskEdit(ctx, chatID)
+		require.NoError(t, err)
+		_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+		require.NoError(t, err)
+		_, err = taskUsecase.DeleteCurrentTask(ctx, chatID)
+		require.ErrorIs(t, err, ErrBadTaskEvent)
+		err = taskUsecase.StartTaskDelete(ctx, chatID)
+		require.NoError(t, err)
+		taskID, err := taskUsecase.DeleteCurrentTask(ctx, chatID)
+		require.NoError(t, err)
+		return taskID
+	}
+
+	taskID := deleteTask()
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	err = taskUsecase.UndoDeleteTask(ctx, generateChatID(), taskID)
+	require.ErrorIs(t, err, ErrNotInTrash)
+	err = taskUsecase.UndoDeleteTask(ctx, chatID, taskID)
+	require.NoError(t, err)
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+
+	taskID = deleteTask()
+	deleted, err := taskUsecase.GetDeletedTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	require.Equal(t, taskID, deleted[0].ID)
+
+	err = taskUsecase.RestoreTask(ctx, chatID, taskID)
+	require.NoError(t, err)
+	err = taskUsecase.RestoreTask(ctx, chatID, taskID)
+	require.ErrorIs(t, err, ErrNotInTrash)
+
+	taskID = deleteTask()
+	purged, err := taskUsecase.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged)
+	purged, err = taskUsecase.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+	err = taskUsecase.RestoreTask(ctx, chatID, taskID)
+	require.ErrorIs(t, err, ErrNotInTrash)
+}
+
+func TestPauseAndVacation(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить кактус", "2 недели")
+	createTestTask(t, taskUsecase, chatID, "Почистить чайник", "месяц")
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	cactus, kettle := tasks[0], tasks[1]
+
+	_, err = taskUsecase.ToggleTaskPause(ctx, chatID)
+	require.ErrorIs(t, err, sqlite_repo.ErrNoTaskEvent)
+	err = taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	paused, err := taskUsecase.ToggleTaskPause(ctx, chatID)
+	require.NoError(t, err)
+	require.True(t, paused.Paused())
+
+	// pretend the task was paused two days ago
+	err = storages.tasks.PauseTask(ctx, cactus.ID, time.Now().Add(-48*time.Hour))
+	require.NoError(t, err)
+	resumed, err := taskUsecase.ToggleTaskPause(ctx, chatID)
+	require.NoError(t, err)
+	require.False(t, resumed.Paused())
+	require.InDelta(t, (48 * time.Hour).Seconds(), resumed.LastReminded.Sub(cactus.LastReminded).Seconds(), 5)
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	_, err = taskUsecase.StartVacation(ctx, chatID, "когда-нибудь")
+	require.ErrorIs(t, err, ErrParseVacation)
+	until, err := taskUsecase.StartVacation(ctx, chatID, "2 недели")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(14*24*time.Hour), until, time.Minute)
+	chat, err := taskUsecase.GetChat(ctx, chatID)
+	require.NoError(t, err)
+	require.True(t, chat.OnVacation(time.Now()))
+
+	// vacation from three days ago that ended an hour ago
+	err = storages.chats.SetVacation(ctx, chatID, time.Now().Add(-72*time.Hour), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	chat, err = taskUsecase.GetChat(ctx, chatID)
+	require.NoError(t, err)
+	require.False(t, chat.OnVacation(time.Now()))
+	require.True(t, chat.VacationOver(time.Now()))
+	shift, err := taskUsecase.EndVacation(ctx, chatID)
+	require.NoError(t, err)
+	require.InDelta(t, (71 * time.Hour).Seconds(), shift.Seconds(), 5)
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, kettle.LastReminded.Add(71*time.Hour).Unix(), tasks[1].LastReminded.Unix())
+
+	_, err = taskUsecase.EndVacation(ctx, chatID)
+	require.ErrorIs(t, err, ErrNoVacation)
+}
+
+func TestSkipTask(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Покосить газон", "неделя")
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	task := tasks[0]
+
+	lastReminded := time.Now().Add(-10 * 24 * time.Hour)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: task.ID, LastReminded: &lastReminded})
+	require.NoError(t, err)
+
+	_, err = taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.ErrorIs(t, err, sqlite_repo.ErrNoTaskEvent)
+	res, err := taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRemindMessageResult())
+	next, err := taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.NoError(t, err)
+	require.True(t, next.After(time.Now()))
+
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, lastReminded.Add(7*24*time.Hour).Unix(), tasks[0].LastReminded.Unix())
+	require.Equal(t, next, tasks[0].NextRemind())
+
+	history, err := storages.history.GetChatHistory(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, entities.HistorySkipped, history[0].Kind)
+
+	// a passed deadline is dropped with the skipped occurrence, the one ahead is kept
+	passed := time.Now().Add(-48 * time.Hour)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: task.ID, DueAt: &passed})
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	next, err = taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.NoError(t, err)
+	require.True(t, next.After(time.Now()))
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.True(t, task.DueAt.IsZero())
+	require.Equal(t, next, task.NextRemind())
+
+	ahead := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: task.ID, DueAt: &ahead})
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	_, err = taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.NoError(t, err)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.True(t, ahead.Equal(task.DueAt))
+
+	noRegularity := time.Duration(0)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: task.ID, Regularity: &noRegularity})
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	_, err = taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.ErrorIs(t, err, entities.ErrNoSchedule)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	day := 24 * time.Hour
+	// reminders come at 15:00 UTC, the skip is pressed after them
+	now := time.Date(2024, time.November, 8, 16, 0, 0, 0, time.UTC)
+	task := entities.UserTask{Regularity: 7 * day, LastReminded: now.Add(-10 * day)}
+	require.Equal(t, now.Add(-3*day), nextOccurrence(task, now))
+
+	// a passed deadline or reached counter does not hold the schedule on its day
+	task.DueAt = now.Add(-2 * day)
+	task.CounterReachedAt = now.Add(-day)
+	require.Equal(t, now.Add(-3*day), nextOccurrence(task, now))
+	task.CounterReachedAt = time.Time{}
+
+	// missed periods are skipped at once
+	task.LastReminded = now.Add(-365 * day)
+	next := nextOccurrence(task, now)
+	task.LastReminded, task.DueAt = next, time.Time{}
+	require.True(t, task.NextRemind().After(now))
+	require.False(t, task.NextRemind().After(now.Add(7*day)))
+}
+
+func TestDoneTask(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить", "неделя")
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "3 дня")
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+
+	task, doneAt, err := taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "полить Цветы вчера")
+	require.NoError(t, err)
+	require.Equal(t, tasks[1].ID, task.ID)
+	require.WithinDuration(t, time.Now().Add(-24*time.Hour), doneAt, time.Minute)
+
+	// done pending reminder is closed
+	_, err = taskUsecase.HandleRemind(ctx, chatID, tasks[0].ID)
+	require.NoError(t, err)
+	task, doneAt, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+	require.Equal(t, tasks[0].ID, task.ID)
+	require.WithinDuration(t, time.Now(), doneAt, time.Minute)
+	_, err = taskUsecase.CurrentEventType(ctx, chatID)
+	require.ErrorIs(t, err, sqlite_repo.ErrNoTaskEvent)
+
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "3")
+	require.ErrorIs(t, err, ErrBadTaskNumber)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "помыть окна")
+	require.ErrorIs(t, err, ErrUnknownTask)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1 завтра")
+	require.ErrorIs(t, err, ErrParseDate)
+
+	err = taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "2")
+	require.NoError(t, err)
+	anna := entities.Member{ID: 1, Name: "Анна Петрова"}
+	err = taskUsecase.StartTaskDone(ctx, chatID, anna)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "через неделю")
+	require.ErrorIs(t, err, ErrParseDate)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "3 дня назад")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditDoneTaskResult())
+	task, err = taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(-72*time.Hour), task.LastReminded, time.Minute)
+
+	history, err := storages.history.GetChatHistory(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for _, record := range history {
+		require.Equal(t, entities.HistoryCompleted, record.Kind)
+	}
+	require.Equal(t, task.LastReminded.Unix(), history[0].DoneAt.Unix())
+	require.Equal(t, anna.ID, history[0].UserID)
+	require.Equal(t, anna.Name, history[0].UserName)
+}
+
+func TestRemindLater(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "неделя")
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+
+	_, err = taskUsecase.HandleRemind(ctx, chatID, tasks[0].ID)
+	require.NoError(t, err)
+	petya := entities.Member{ID: 2, Name: "Петя"}
+	err = taskUsecase.RemindLater(ctx, chatID, petya)
+	require.NoError(t, err)
+
+	// snoozes are not occurrences and stay out of history and export
+	history, err := storages.history.GetChatHistory(ctx, chatID)
+	require.NoError(t, err)
+	require.Empty(t, history)
+	snoozes, err := storages.history.GetChatSnoozes(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, snoozes, 1)
+	require.Equal(t, tasks[0].ID, snoozes[0].TaskID)
+	require.Equal(t, petya.ID, snoozes[0].UserID)
+	stats, err := taskUsecase.GetStats(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Snoozed)
+	require.Equal(t, 1, stats.Tasks[0].Snoozed)
+	export, err := taskUsecase.ExportChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Empty(t, export.History)
+}
+
+func TestTaskAnchor(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Вынести мусорные баки", "неделя")
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	anchor, err := taskUsecase.ToggleTaskAnchor(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, entities.AnchorFixed, anchor)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "никогда")
+	require.ErrorIs(t, err, ErrParseDate)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, today.Format("02.01.2006"))
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditAnchorTaskResult())
+
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, entities.AnchorFixed, task.Anchor)
+	require.Equal(t, today.Unix(), task.StartAt.Unix())
+	// the task was done today, so the next occurrence is in a week
+	require.Equal(t, today.Add(7*24*time.Hour).Unix(), task.NextDue().Unix())
+
+	anchor, err = taskUsecase.ToggleTaskAnchor(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, entities.AnchorCompletion, anchor)
+	task, err = taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, entities.AnchorCompletion, task.Anchor)
+}
+
+func TestOneOffTask(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	err := taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "Продлить страховку")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskNameCreated())
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Один раз")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedDueDate())
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "когда-нибудь")
+	require.ErrorIs(t, err, ErrParseDate)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "15 ноября")
+	require.NoError(t, err)
+	require.True(t, res.IsTaskCreated())
+
+	// the date instead of the kind makes a one-off task too
+	parcelDate := time.Now().UTC().Truncate(24 * time.Hour).Add(72 * time.Hour)
+	err = taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Забрать посылку")
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, parcelDate.Format("02.01"))
+	require.NoError(t, err)
+	require.True(t, res.IsTaskCreated())
+
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	insurance, parcel := tasks[0], tasks[1]
+	require.True(t, insurance.OneOff)
+	require.Equal(t, time.November, insurance.DueAt.UTC().Month())
+	require.Equal(t, 15, insurance.DueAt.UTC().Day())
+	require.Equal(t, insurance.DueAt.Unix(), insurance.NextDue().Unix())
+	require.True(t, parcel.OneOff)
+	require.Equal(t, parcelDate.Unix(), parcel.DueAt.Unix())
+
+	export, err := taskUsecase.ExportChat(ctx, chatID)
+	require.NoError(t, err)
+	require.NoError(t, transfer.Validate(export))
+	require.True(t, export.Tasks[0].OneOff)
+
+	// completed one-off task is archived
+	_, err = taskUsecase.HandleRemind(ctx, chatID, parcel.ID)
+	require.NoError(t, err)
+	err = taskUsecase.CompleteTask(ctx, chatID, entities.Member{})
+	require.NoError(t, err)
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	archived, err := storages.tasks.GetTask(ctx, parcel.ID)
+	require.NoError(t, err)
+	require.False(t, archived.ArchivedAt.IsZero())
+
+	// "через день" is a regularity, not a date
+	err = taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Полить цветы")
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "через день")
+	require.NoError(t, err)
+	require.True(t, res.IsRegularityParsed())
+	flowers, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.False(t, flowers.OneOff)
+	require.Equal(t, 24*time.Hour, flowers.Regularity)
+}
+
+func TestTaskDeadline(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Поменять фильтр", "месяц")
+
+	setDeadline := func(message string) entities.UserTask {
+		err := taskUsecase.StartTaskDueDateEdit(ctx, chatID)
+		require.NoError(t, err)
+		res, err := taskUsecase.HandleTaskMessage(ctx, chatID, message)
+		require.NoError(t, err)
+		require.True(t, res.IsGotEditDueDateTaskResult())
+		task, err := taskUsecase.CurrentTask(ctx, chatID)
+		require.NoError(t, err)
+		return task
+	}
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	task := setDeadline("через 2 дня")
+	require.False(t, task.OneOff)
+	require.Equal(t, task.DueAt.Truncate(24*time.Hour).Unix(), task.NextDue().Unix())
+	task = setDeadline("нет")
+	require.True(t, task.DueAt.IsZero())
+	setDeadline("через неделю")
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	// the deadline is met with completion
+	task, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.True(t, task.DueAt.IsZero())
+}
+
+func TestTaskNotes(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Почистить кофемашину", "месяц")
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+
+	// attachments are only accepted while editing notes
+	err = taskUsecase.AddTaskAttachment(ctx, chatID, "photo-1", entities.AttachmentPhoto)
+	require.ErrorIs(t, err, ErrBadTaskEvent)
+
+	err = taskUsecase.StartTaskNotesEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "Таблетка в верхнем ящике")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditNotesTaskResult())
+	err = taskUsecase.AddTaskAttachment(ctx, chatID, "photo-1", entities.AttachmentPhoto)
+	require.NoError(t, err)
+	err = taskUsecase.AddTaskAttachment(ctx, chatID, "manual-1", entities.AttachmentDocument)
+	require.NoError(t, err)
+	err = taskUsecase.FinishTaskNotesEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, "Таблетка в верхнем ящике", task.Notes)
+	attachments, err := taskUsecase.GetTaskAttachments(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	require.Equal(t, "photo-1", attachments[0].FileID)
+	require.Equal(t, entities.AttachmentPhoto, attachments[0].Kind)
+	require.Equal(t, entities.AttachmentDocument, attachments[1].Kind)
+
+	export, err := taskUsecase.ExportChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, "Таблетка в верхнем ящике", *export.Tasks[0].Notes)
+
+	err = taskUsecase.StartTaskNotesEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "очистить")
+	require.NoError(t, err)
+	require.True(t, res.IsClearedNotesTaskResult())
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Empty(t, task.Notes)
+	attachments, err = taskUsecase.GetTaskAttachments(ctx, task.ID)
+	require.NoError(t, err)
+	require.Empty(t, attachments)
+}
+
+func TestCompletionProof(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Убрать в комнате", "неделя")
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	task, err := taskUsecase.ToggleTaskProof(ctx, chatID)
+	require.NoError(t, err)
+	require.True(t, task.RequiresProof)
+	err = taskUsecase.StartTaskDone(ctx, chatID, entities.Member{})
+	require.ErrorIs(t, err, entities.ErrProofRequired)
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.ErrorIs(t, err, entities.ErrProofRequired)
+
+	res, err := taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRemindMessageResult())
+	// the photo is not taken before completion is asked
+	err = taskUsecase.CompleteTaskWithProof(ctx, chatID, entities.Member{}, "photo-1")
+	require.ErrorIs(t, err, ErrBadTaskEvent)
+	err = taskUsecase.CompleteTask(ctx, chatID, entities.Member{})
+	require.ErrorIs(t, err, entities.ErrProofRequired)
+	err = taskUsecase.CompleteTaskWithProof(ctx, chatID, entities.Member{}, "photo-1")
+	require.NoError(t, err)
+
+	_, err = storages.events.GetCurrentTaskEvent(ctx, chatID)
+	require.ErrorIs(t, err, sqlite_repo.ErrNoTaskEvent)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), task.LastReminded, time.Minute)
+
+	err = taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	proofs, err := taskUsecase.GetTaskProofs(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, proofs, 1)
+	require.Equal(t, "photo-1", proofs[0].ProofFileID)
+	require.Equal(t, entities.HistoryCompleted, proofs[0].Kind)
+}
+
+func TestTaskCategories(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+
+	err := taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Помыть плиту")
+	require.NoError(t, err)
+	// the category is asked only on demand
+	err = taskUsecase.ChooseTaskCategory(ctx, chatID, "Кухня")
+	require.ErrorIs(t, err, ErrBadTaskEvent)
+	err = taskUsecase.StartTaskCategoryChoice(ctx, chatID)
+	require.NoError(t, err)
+	err = taskUsecase.ChooseTaskCategory(ctx, chatID, "Кухня")
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "неделя")
+	require.NoError(t, err)
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
+
+	// the typed category is matched with the known one
+	err = taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Разморозить холодильник")
+	require.NoError(t, err)
+	err = taskUsecase.StartTaskCategoryChoice(ctx, chatID)
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "кухня #Долго #долго")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedTaskKind())
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "3 месяца")
+	require.NoError(t, err)
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
+
+	createTestTask(t, taskUsecase, chatID, "Поменять масло", "год")
+
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	require.Equal(t, "Кухня", tasks[0].Category)
+	require.Empty(t, tasks[0].Tags)
+	require.Equal(t, "Кухня", tasks[1].Category)
+	require.Equal(t, []string{"долго"}, tasks[1].Tags)
+	require.True(t, tasks[1].HasLabel("#ДОЛГО"))
+	require.True(t, tasks[1].HasLabel("кухня"))
+	require.False(t, tasks[2].HasLabel("кухня"))
+
+	categories, err := taskUsecase.GetCategories(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, "Кухня", categories[0])
+	require.Len(t, categories, len(entities.DefaultCategories))
+
+	err = taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "3")
+	require.NoError(t, err)
+	err = taskUsecase.StartTaskCategoryEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Машина #сезонное")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditCategoryTaskResult())
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, "Машина", task.Category)
+	require.Equal(t, []string{"сезонное"}, task.Tags)
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	export, err := taskUsecase.ExportChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, "Машина", *export.Tasks[2].Category)
+	require.Equal(t, []string{"сезонное"}, *export.Tasks[2].Tags)
+
+	// empty values clear the fields, missing ones keep them
+	noCategory := ""
+	export.Tasks[2].Category = &noCategory
+	export.Tasks[2].Tags = &[]string{}
+	export.Tasks[1].Category = nil
+	export.Tasks[1].Tags = nil
+	err = taskUsecase.StartImport(ctx, chatID)
+	require.NoError(t, err)
+	diff, err := taskUsecase.PreviewImport(ctx, chatID, "file", export)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Поменять масло"}, diff.Updated)
+	_, err = taskUsecase.ApplyImport(ctx, chatID, export)
+	require.NoError(t, err)
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Empty(t, tasks[2].Category)
+	require.Empty(t, tasks[2].Tags)
+	require.Equal(t, "Кухня", tasks[1].Category)
+	require.Equal(t, []string{"долго"}, tasks[1].Tags)
+}
+
+func TestTaskChecklist(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Генеральная уборка", "месяц")
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+	require.NoError(t, err)
+	err = taskUsecase.StartTaskChecklistEdit(ctx, chatID)
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "1. Пропылесосить\n- Помыть полы\n\n• Протереть пыль.")
+	require.NoError(t, err)
+	require.True(t, res.IsGotEditChecklistTaskResult())
+	task, err := taskUsecase.CurrentTask(ctx, chatID)
+	require.NoError(t, err)
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	items, err := taskUsecase.GetTaskChecklist(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, "Пропылесосить", items[0].Text)
+	require.Equal(t, "Помыть полы", items[1].Text)
+	require.Equal(t, "Протереть пыль.", items[2].Text)
+
+	// items are only ticked during a reminder
+	_, err = taskUsecase.ToggleChecklistItem(ctx, chatID, entities.Member{}, items[0].ID)
+	require.ErrorIs(t, err, entities.ErrReminderClosed)
+
+	_, err = taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	for _, item := range items[:2] {
+		_, err = taskUsecase.ToggleChecklistItem(ctx, chatID, entities.Member{}, item.ID)
+		require.NoError(t, err)
+	}
+	// unticked again
+	toggled, err := taskUsecase.ToggleChecklistItem(ctx, chatID, entities.Member{}, items[1].ID)
+	require.NoError(t, err)
+	require.True(t, toggled[0].Done)
+	require.False(t, toggled[1].Done)
+	_, err = taskUsecase.ToggleChecklistItem(ctx, chatID, entities.Member{}, items[1].ID)
+	require.NoError(t, err)
+	toggled, err = taskUsecase.ToggleChecklistItem(ctx, chatID, entities.Member{}, items[2].ID)
+	require.NoError(t, err)
+	require.True(t, entities.ChecklistDone(toggled))
+
+	// the last item completes the task and the checklist starts over
+	_, err = storages.events.GetCurrentTaskEvent(ctx, chatID)
+	require.ErrorIs(t, err, sqlite_repo.ErrNoTaskEvent)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), task.LastReminded, time.Minute)
+	items, err = taskUsecase.GetTaskChecklist(ctx, task.ID)
+	require.NoError(t, err)
+	for _, item := range items {
+		require.False(t, item.Done)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Разморозить морозилку", "3 месяца")
+	createTestTask(t, taskUsecase, chatID, "Помыть морозилку", "3 месяца")
+	createTestTask(t, taskUsecase, chatID, "Разложить продукты", "3 месяца")
+
+	setDependency := func(taskNum string, message string) error {
+		err := taskUsecase.StartTaskEdit(ctx, chatID)
+		require.NoError(t, err)
+		_, err = taskUsecase.HandleTaskMessage(ctx, chatID, taskNum)
+		require.NoError(t, err)
+		err = taskUsecase.StartTaskDependencyEdit(ctx, chatID)
+		require.NoError(t, err)
+		_, err = taskUsecase.HandleTaskMessage(ctx, chatID, message)
+		stopErr := taskUsecase.StopTaskEdit(ctx, chatID)
+		require.NoError(t, stopErr)
+		return err
+	}
+	require.NoError(t, setDependency("2", "разморозить морозилку через 1 день"))
+	require.NoError(t, setDependency("3", "2"))
+	require.ErrorIs(t, setDependency("1", "3 через 2 дня"), ErrDependencyCycle)
+	require.ErrorIs(t, setDependency("1", "1"), ErrDependencyCycle)
+
+	err := taskUsecase.StartTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "2")
+	require.NoError(t, err)
+	chain, err := taskUsecase.GetTaskChain(ctx, chatID)
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+	require.Equal(t, "Разморозить морозилку", chain[0].Name)
+	require.Equal(t, "Помыть морозилку", chain[1].Name)
+	require.Equal(t, 24*time.Hour, chain[1].AfterDelay)
+	require.Equal(t, "Разложить продукты", chain[2].Name)
+	err = taskUsecase.StopTaskEdit(ctx, chatID)
+	require.NoError(t, err)
+
+	// completing the first task makes the next one due a day later
+	defrost, doneAt, err := taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, defrost.ID, tasks[1].AfterTaskID)
+	require.Equal(t, doneAt.Add(24*time.Hour).Unix(), tasks[1].ChainedAt.Unix())
+	require.Equal(t, doneAt.Add(24*time.Hour).Truncate(24*time.Hour).Unix(), tasks[1].NextDue().Unix())
+	require.True(t, tasks[2].ChainedAt.IsZero())
+
+	// the deadline of the following task is not taken by the chained date
+	deadline := time.Now().Add(40 * 24 * time.Hour).Truncate(time.Second)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: tasks[2].ID, DueAt: &deadline})
+	require.NoError(t, err)
+	_, doneAt, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "2")
+	require.NoError(t, err)
+	tasks, err = taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.True(t, tasks[1].ChainedAt.IsZero())
+	require.Equal(t, doneAt.Unix(), tasks[2].ChainedAt.Unix())
+	require.True(t, deadline.Equal(tasks[2].DueAt))
+	require.Equal(t, doneAt.Truncate(24*time.Hour).Unix(), tasks[2].NextDue().Unix())
+
+	// purging the task followed leaves the next one independent
+	err = storages.tasks.DeleteTask(ctx, tasks[1].ID)
+	require.NoError(t, err)
+	_, err = taskUsecase.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	task, err := storages.tasks.GetTask(ctx, tasks[2].ID)
+	require.NoError(t, err)
+	require.Zero(t, task.AfterTaskID)
+	require.True(t, task.ChainedAt.IsZero())
+	require.NoError(t, setDependency("2", "1"))
+
+	require.NoError(t, setDependency("2", "нет"))
+	task, err = storages.tasks.GetTask(ctx, tasks[2].ID)
+	require.NoError(t, err)
+	require.Zero(t, task.AfterTaskID)
+}
+
+func TestCounterTask(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "неделя")
+
+	err := taskUsecase.CreateEmptyTask(ctx, chatID)
+	require.NoError(t, err)
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "Поменять фильтр")
+	require.NoError(t, err)
+	res, err := taskUsecase.HandleTaskMessage(ctx, chatID, "по счетчику")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedThreshold())
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "много")
+	require.ErrorIs(t, err, ErrParseAmount)
+	res, err = taskUsecase.HandleTaskMessage(ctx, chatID, "300,5 литров")
+	require.NoError(t, err)
+	require.True(t, res.IsNeedRegularity())
+	_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "6 месяцев")
+	require.NoError(t, err)
+	err = taskUsecase.ConfirmTaskRegularity(ctx, chatID)
+	require.NoError(t, err)
+
+	_, _, err = taskUsecase.LogCounter(ctx, chatID, "1 10")
+	require.ErrorIs(t, err, ErrNotCounterTask)
+
+	task, predicted, err := taskUsecase.LogCounter(ctx, chatID, "поменять фильтр 100")
+	require.NoError(t, err)
+	require.True(t, task.CounterTask())
+	require.Equal(t, 300.5, task.Threshold)
+	require.Equal(t, "литров", task.CounterUnit)
+	require.Equal(t, 100.0, task.Counter)
+	require.False(t, task.CounterReached())
+	require.True(t, predicted.IsZero())
+	require.Equal(t, task.LastReminded.Truncate(24*time.Hour).Add(task.Regularity).Unix(), task.NextDue().Unix())
+
+	task, _, err = taskUsecase.LogCounter(ctx, chatID, "2 250л")
+	require.NoError(t, err)
+	require.True(t, task.CounterReached())
+	stored, err := storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, 350.0, stored.Counter)
+	require.Equal(t, time.Now().Truncate(24*time.Hour).Unix(), stored.NextDue().Unix())
+	require.True(t, stored.DueAt.IsZero())
+
+	// a skip keeps the usage, the next reading makes the task due again
+	_, err = taskUsecase.HandleRemind(ctx, chatID, task.ID)
+	require.NoError(t, err)
+	next, err := taskUsecase.SkipTask(ctx, chatID, entities.Member{})
+	require.NoError(t, err)
+	require.True(t, next.After(time.Now()))
+	stored, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, 350.0, stored.Counter)
+	require.True(t, stored.CounterReachedAt.IsZero())
+	require.Equal(t, next, stored.NextRemind())
+	_, _, err = taskUsecase.LogCounter(ctx, chatID, "2 10")
+	require.NoError(t, err)
+	stored, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, time.Now().Truncate(24*time.Hour).Unix(), stored.NextDue().Unix())
+
+	// a user deadline is not taken by the counter
+	deadline := time.Now().Add(20 * 24 * time.Hour).Truncate(time.Second)
+	err = storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: task.ID, DueAt: &deadline})
+	require.NoError(t, err)
+	_, _, err = taskUsecase.LogCounter(ctx, chatID, "2 10")
+	require.NoError(t, err)
+	stored, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.True(t, deadline.Equal(stored.DueAt))
+
+	// completion starts counting over
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "2")
+	require.NoError(t, err)
+	stored, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Zero(t, stored.Counter)
+	require.True(t, stored.CounterReachedAt.IsZero())
+	require.True(t, stored.DueAt.IsZero())
+}
+
+func TestPredictCounter(t *testing.T) {
+	now := time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)
+	task := entities.UserTask{Threshold: 1000, Counter: 400}
+	readings := []entities.Reading{
+		{Value: 300, LoggedAt: now.Add(-10 * 24 * time.Hour)},
+		{Value: 50, LoggedAt: now.Add(-5 * 24 * time.Hour)},
+		{Value: 50, LoggedAt: now},
+	}
+	// 100 in 10 days, 600 left
+	require.Equal(t, now.Add(60*24*time.Hour), predictCounter(task, readings, now))
+	require.True(t, predictCounter(task, readings[2:], now).IsZero())
+	task.Counter = 1000
+	require.Equal(t, now, predictCounter(task, readings, now))
+}
+
+func TestTaskSeason(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить огород", "1 неделя")
+
+	setSeason := func(message string) error {
+		err := taskUsecase.StartTaskEdit(ctx, chatID)
+		require.NoError(t, err)
+		_, err = taskUsecase.HandleTaskMessage(ctx, chatID, "1")
+		require.NoError(t, err)
+		err = taskUsecase.StartTaskSeasonEdit(ctx, chatID)
+		require.NoError(t, err)
+		res, err := taskUsecase.HandleTaskMessage(ctx, chatID, message)
+		stopErr := taskUsecase.StopTaskEdit(ctx, chatID)
+		require.NoError(t, stopErr)
+		if err == nil {
+			require.True(t, res.IsGotEditSeasonTaskResult())
+		}
+		return err
+	}
+	require.ErrorIs(t, setSeason("когда-нибудь"), ErrParseSeason)
+	require.NoError(t, setSeason("с апреля по октябрь, 1 декабря - 15 января"))
+
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, []regularity.Window{
+		{FromMonth: time.April, FromDay: 1, ToMonth: time.October, ToDay: 31},
+		{FromMonth: time.December, FromDay: 1, ToMonth: time.January, ToDay: 15},
+	}, tasks[0].Season)
+
+	task := tasks[0]
+	require.True(t, task.InSeason(time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)))
+	require.True(t, task.InSeason(time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC)))
+	require.False(t, task.InSeason(time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)))
+	require.False(t, task.InSeason(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)))
+
+	// out of season the task is due when the season opens
+	task.LastReminded = time.Date(2024, 10, 28, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), task.NextDue())
+	task.LastReminded = time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), task.NextDue())
+	task.LastReminded = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC), task.NextDue())
+
+	require.NoError(t, setSeason("круглый год"))
+	stored, err := storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.Season)
+	require.True(t, stored.InSeason(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseDigest(t *testing.T) {
+	cases := map[string]entities.DigestSettings{
+		"каждый день в 9":             {Period: entities.DigestDaily, Hour: 9},
+		"ежедневно 7:30 только":       {Period: entities.DigestDaily, Hour: 7, Only: true},
+		"по пятницам в 18":            {Period: entities.DigestWeekly, Hour: 18, Weekday: time.Friday},
+		"еженедельно":                 {Period: entities.DigestWeekly, Hour: defaultDigestHour, Weekday: time.Monday},
+		"еженедельно по воскресеньям": {Period: entities.DigestWeekly, Hour: defaultDigestHour, Weekday: time.Sunday},
+		"выкл": {Period: entities.DigestOff},
+	}
+	for message, settings := range cases {
+		res, err := parseDigest(message)
+		require.NoError(t, err, message)
+		require.Equal(t, settings, res, message)
+	}
+	for _, message := range []string{"когда-нибудь", "каждый день в 25"} {
+		_, err := parseDigest(message)
+		require.ErrorIs(t, err, ErrParseDigest, message)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "3 дня")
+	createTestTask(t, taskUsecase, chatID, "Помыть окна", "3 месяца")
+	createTestTask(t, taskUsecase, chatID, "Поменять постельное", "2 недели")
+
+	settings, err := taskUsecase.SetDigest(ctx, chatID, "по понедельникам в 8")
+	require.NoError(t, err)
+	chat, err := taskUsecase.GetChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, settings, chat.Digest)
+	require.False(t, chat.DigestDue(time.Now()))
+
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	now := time.Now()
+	// flowers are overdue, windows are far away, bedding is due this week
+	lastReminded := now.Add(-10 * 24 * time.Hour)
+	require.NoError(t, storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: tasks[0].ID, LastReminded: &lastReminded}))
+	require.NoError(t, storages.tasks.UpdateTask(ctx, entities.TaskUpdate{TaskID: tasks[2].ID, LastReminded: &lastReminded}))
+	for _, doneAt := range []time.Time{now.Add(-8 * 24 * time.Hour), now.Add(-3 * 24 * time.Hour), now.Add(-24 * time.Hour)} {
+		_, err = storages.history.AddRecord(ctx, entities.HistoryRecord{ChatID: chatID, TaskID: tasks[1].ID, Kind: entities.HistoryCompleted, DoneAt: doneAt})
+		require.NoError(t, err)
+	}
+	_, err = storages.history.AddRecord(ctx, entities.HistoryRecord{ChatID: chatID, TaskID: tasks[0].ID, Kind: entities.HistorySkipped, DoneAt: now.Add(-time.Hour)})
+	require.NoError(t, err)
+
+	digest, err := taskUsecase.BuildDigest(ctx, chatID, now)
+	require.NoError(t, err)
+	require.Equal(t, entities.DigestWeekly, digest.Period)
+	require.Len(t, digest.Overdue, 1)
+	require.Equal(t, "Полить цветы", digest.Overdue[0].Name)
+	require.Len(t, digest.Upcoming, 1)
+	require.Equal(t, "Поменять постельное", digest.Upcoming[0].Name)
+	require.Len(t, digest.Done, 1)
+	require.Equal(t, "Помыть окна", digest.Done[0].Task.Name)
+	require.Equal(t, 2, digest.Done[0].Times)
+
+	require.NoError(t, taskUsecase.MarkDigestSent(ctx, chatID, now))
+	chat, err = taskUsecase.GetChat(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, now.Unix(), chat.DigestSentAt.Unix())
+}
+
+func TestParseQuiet(t *testing.T) {
+	current := entities.QuietHours{Offset: 3 * time.Hour}
+	cases := map[string]entities.QuietHours{
+		"22-8": {From: 22 * time.Hour, Until: 8 * time.Hour, Offset: 3 * time.Hour},
+		"с 23:30 до 7 выходные до 10": {
+			From: 23*time.Hour + 30*time.Minute, Until: 7 * time.Hour, WeekendUntil: 10 * time.Hour, Offset: 3 * time.Hour,
+		},
+		"22 — 8 utc+5": {From: 22 * time.Hour, Until: 8 * time.Hour, Offset: 5 * time.Hour},
+		"UTC-4:30":     {Offset: -4*time.Hour - 30*time.Minute},
+		"20-24":        {From: 20 * time.Hour, Until: 24 * time.Hour, Offset: 3 * time.Hour},
+		"выкл":         {Offset: 3 * time.Hour},
+	}
+	for message, quiet := range cases {
+		res, err := parseQuiet(message, current)
+		require.NoError(t, err, message)
+		require.Equal(t, quiet, res, message)
+	}
+	for _, message := range []string{"ночью", "22", "25-8", "22-8 выходные", "utc+20", "24:30-8", "22-8:5"} {
+		_, err := parseQuiet(message, current)
+		require.ErrorIs(t, err, ErrParseQuiet, message)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, time.September, 1, 10, 0, 0, 0, time.UTC)
+	now := start.Add(40 * day)
+	flowers := entities.UserTask{ID: 1, Name: "Полить цветы", Regularity: 7 * day, LastReminded: start.Add(35 * day)}
+	windows := entities.UserTask{ID: 2, Name: "Помыть окна", Regularity: 7 * day, LastReminded: start.Add(10 * day)}
+	anna := entities.Member{ID: 1, Name: "Аня"}
+	petya := entities.Member{ID: 2, Name: "Петя"}
+	record := func(task entities.UserTask, kind entities.HistoryKind, at time.Duration, member entities.Member) entities.HistoryRecord {
+		return entities.HistoryRecord{TaskID: task.ID, Kind: kind, DoneAt: start.Add(at), UserID: member.ID, UserName: member.Name}
+	}
+	history := []entities.HistoryRecord{
+		record(flowers, entities.HistoryCompleted, 0, anna),
+		record(flowers, entities.HistoryCompleted, 7*day, anna),
+		// three days late breaks the streak
+		record(flowers, entities.HistoryCompleted, 17*day, petya),
+		record(flowers, entities.HistoryCompleted, 24*day, anna),
+		record(flowers, entities.HistorySkipped, 30*day, anna),
+		record(flowers, entities.HistoryCompleted, 35*day, anna),
+		record(windows, entities.HistoryCompleted, 3*day, petya),
+		record(windows, entities.HistoryCompleted, 10*day, entities.Member{}),
+		// deleted task counts for members only
+		{TaskID: 3, Kind: entities.HistoryCompleted, DoneAt: start, UserID: petya.ID, UserName: petya.Name},
+	}
+
+	snoozes := []entities.Snooze{{TaskID: flowers.ID, SnoozedAt: start.Add(13 * day), UserID: petya.ID, UserName: petya.Name}}
+
+	stats := computeStats([]entities.UserTask{flowers, windows}, history, snoozes, now)
+	require.Equal(t, 8, stats.Completed)
+	require.Equal(t, 1, stats.Skipped)
+	require.Equal(t, 1, stats.Snoozed)
+
+	flowerStats := stats.Tasks[0]
+	require.Equal(t, 5, flowerStats.Completed)
+	require.Equal(t, 4, flowerStats.Rated)
+	require.Equal(t, 3, flowerStats.OnTime)
+	require.Equal(t, 0.75, flowerStats.OnTimeRate())
+	// 0, +3, 0 and -2 days after the skip
+	require.Equal(t, day/4, flowerStats.AvgDelay)
+	require.Equal(t, 1, flowerStats.Skipped)
+	require.Equal(t, 1, flowerStats.Snoozed)
+	require.Equal(t, 2, flowerStats.CurrentStreak)
+	require.Equal(t, 2, flowerStats.BestStreak)
+
+	// windows are overdue since the 17th day
+	windowStats := stats.Tasks[1]
+	require.Equal(t, 1, windowStats.BestStreak)
+	require.Zero(t, windowStats.CurrentStreak)
+
+	require.Len(t, stats.Members, 3)
+	require.Equal(t, anna, stats.Members[0].Member)
+	require.Equal(t, 4, stats.Members[0].Completed)
+	require.Equal(t, 0.5, stats.Members[0].Share)
+	require.Equal(t, petya, stats.Members[1].Member)
+	require.Equal(t, 3, stats.Members[1].Completed)
+	require.Zero(t, stats.Members[2].Member.ID)
+}
+
+func TestStatsMembers(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Вынести мусор", "1 день")
+
+	anna := entities.Member{ID: 1, Name: "Аня"}
+	_, _, err := taskUsecase.DoneTask(ctx, chatID, anna, "1 вчера")
+	require.NoError(t, err)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+
+	stats, err := taskUsecase.GetStats(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Completed)
+	require.Len(t, stats.Members, 2)
+	require.Equal(t, anna, stats.Members[0].Member)
+	require.Equal(t, 0.5, stats.Members[0].Share)
+	require.Equal(t, entities.Member{}, stats.Members[1].Member)
+	require.Equal(t, 1, stats.Tasks[0].Rated)
+	require.Equal(t, 1, stats.Tasks[0].CurrentStreak)
+}
+
+func TestTaskOccurrences(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, time.September, 1, 10, 0, 0, 0, time.UTC)
+	task := entities.UserTask{ID: 1, Regularity: 7 * day, LastReminded: start.Add(20 * day)}
+	history := []entities.HistoryRecord{
+		{TaskID: 1, Kind: entities.HistoryCompleted, DoneAt: start},
+		{TaskID: 1, Kind: entities.HistoryCompleted, DoneAt: start.Add(10 * day)},
+		{TaskID: 1, Kind: entities.HistorySkipped, DoneAt: start.Add(17 * day)},
+		{TaskID: 1, Kind: entities.HistoryCompleted, DoneAt: start.Add(20 * day)},
+	}
+	occurrences := taskOccurrences(task, history)
+	require.Equal(t, []chart.Occurrence{
+		{Due: start.Add(7 * day).Truncate(day), Done: start.Add(10 * day), Late: true},
+		{Due: start.Add(17 * day).Truncate(day), Done: start.Add(17 * day), Skipped: true},
+		{Due: start.Add(24 * day).Truncate(day), Done: start.Add(20 * day)},
+		{Due: task.NextDue()},
+	}, occurrences)
+
+	// a fixed schedule does not move after a late completion
+	// and a deadline or the season are respected like in reminders
+	fixed := task
+	fixed.Anchor = entities.AnchorFixed
+	fixed.StartAt = start
+	occurrences = taskOccurrences(fixed, history)
+	require.Equal(t, start.Add(14*day).Truncate(day), occurrences[1].Due)
+	seasonal := task
+	seasonal.Season = []regularity.Window{{FromMonth: 9, FromDay: 20, ToMonth: 10, ToDay: 31}}
+	occurrences = taskOccurrences(seasonal, history)
+	require.Equal(t, time.Date(2024, time.September, 20, 0, 0, 0, 0, time.UTC), occurrences[0].Due)
+}
+
+func TestTaskChart(t *testing.T) {
+	taskUsecase, _ := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "1 неделя")
+	createTestTask(t, taskUsecase, chatID, "Вынести мусор", "1 день")
+
+	anna := entities.Member{ID: 1, Name: "Аня"}
+	petya := entities.Member{ID: 2, Name: "Петя"}
+	_, _, err := taskUsecase.DoneTask(ctx, chatID, petya, "полить цветы 3 дня назад")
+	require.NoError(t, err)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, anna, "полить цветы")
+	require.NoError(t, err)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, anna, "вынести мусор")
+	require.NoError(t, err)
+
+	res, err := taskUsecase.TaskChart(ctx, chatID, "полить цветы")
+	require.NoError(t, err)
+	require.Equal(t, "Полить цветы", res.Task.Name)
+	require.Len(t, res.Members, 2)
+	img, err := png.Decode(bytes.NewReader(res.Image))
+	require.NoError(t, err)
+	require.Equal(t, chart.Width, img.Bounds().Dx())
+
+	res, err = taskUsecase.TaskChart(ctx, chatID, "")
+	require.NoError(t, err)
+	require.Zero(t, res.Task.ID)
+	require.Equal(t, []entities.Member{anna, petya}, res.Members)
+
+	_, err = taskUsecase.TaskChart(ctx, chatID, "помыть слона")
+	require.ErrorIs(t, err, ErrUnknownTask)
+}
+
+func TestSuggestRegularity(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, time.September, 1, 10, 0, 0, 0, time.UTC)
+	week := entities.UserTask{ID: 1, Regularity: 7 * day}
+	completions := func(days ...int) []entities.HistoryRecord {
+		var res []entities.HistoryRecord
+		for _, d := range days {
+			res = append(res, entities.HistoryRecord{TaskID: 1, Kind: entities.HistoryCompleted, DoneAt: start.Add(time.Duration(d) * day)})
+		}
+		return res
+	}
+	cases := map[string]struct {
+		task      entities.UserTask
+		history   []entities.HistoryRecord
+		suggested time.Duration
+	}{
+		"every ten days":   {week, completions(0, 10, 20, 30, 40), 10 * day},
+		"every five days":  {week, completions(0, 5, 10, 15, 20), 5 * day},
+		"on time":          {week, completions(0, 7, 15, 21, 28), 0},
+		"too few":          {week, completions(0, 10, 20, 30), 0},
+		"a day late":       {week, completions(0, 8, 16, 24, 32), 0},
+		"inconsistent":     {week, completions(0, 10, 17, 27, 34, 44), 0},
+		"latest intervals": {week, completions(0, 7, 14, 21, 28, 38, 48, 58, 68, 78), 10 * day},
+		"over a skip": {week, append(completions(0, 10, 20, 30),
+			entities.HistoryRecord{TaskID: 1, Kind: entities.HistorySkipped, DoneAt: start.Add(37 * day)},
+			completions(47)[0]), 0},
+		"one-off":     {entities.UserTask{ID: 1, OneOff: true}, completions(0, 10, 20, 30, 40), 0},
+		"counter":     {entities.UserTask{ID: 1, Regularity: 7 * day, Threshold: 100}, completions(0, 10, 20, 30, 40), 0},
+		"every hours": {entities.UserTask{ID: 1, Regularity: 12 * time.Hour}, completions(0, 1, 2, 3, 4), 0},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			suggested, ok := suggestRegularity(c.task, c.history, 0)
+			require.Equal(t, c.suggested != 0, ok)
+			require.Equal(t, c.suggested, suggested)
+		})
+	}
+
+	// inconsistent intervals count when the task is snoozed a lot
+	suggested, ok := suggestRegularity(week, completions(0, 10, 17, 27, 34, 44), 5)
+	require.True(t, ok)
+	require.Equal(t, 10*day, suggested)
+}
+
+func TestRegularitySuggestion(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Полить цветы", "7 дней")
+	for _, when := range []string{"40 дней назад", "30 дней назад", "20 дней назад", "10 дней назад"} {
+		_, _, err := taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1 "+when)
+		require.NoError(t, err)
+	}
+	tasks, err := taskUsecase.GetTasks(ctx, chatID)
+	require.NoError(t, err)
+	require.False(t, tasks[0].SuggestionPending())
+
+	task, _, err := taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.True(t, task.SuggestionPending())
+	require.Equal(t, 10*24*time.Hour, task.SuggestedRegularity)
+
+	_, err = taskUsecase.AnswerSuggestion(ctx, generateChatID(), task.ID, true)
+	require.ErrorIs(t, err, entities.ErrNoSuggestion)
+	sentAt := time.Now()
+	require.NoError(t, taskUsecase.MarkSuggestionSent(ctx, task.ID, sentAt))
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.False(t, task.SuggestionPending())
+
+	task, err = taskUsecase.AnswerSuggestion(ctx, chatID, task.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, 10*24*time.Hour, task.Regularity)
+	require.Zero(t, task.SuggestedRegularity)
+	_, err = taskUsecase.AnswerSuggestion(ctx, chatID, task.ID, false)
+	require.ErrorIs(t, err, entities.ErrNoSuggestion)
+
+	// completions before the answered offer are not analysed again
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, entities.Member{}, "1")
+	require.NoError(t, err)
+	task, err = storages.tasks.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.Zero(t, task.SuggestedRegularity)
+}
+
+func TestAwardPoints(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+	task := entities.UserTask{Regularity: 7 * day, LastReminded: start}
+	due := task.NextDue()
+	require.Equal(t, 3, task.DefaultPoints())
+
+	require.Equal(t, 3, awardPoints(task, due.Add(-2*day)))
+	require.Equal(t, 3, awardPoints(task, due.Add(day)))
+	require.Equal(t, 2, awardPoints(task, due.Add(day+day*7/2)))
+	require.Equal(t, 1, awardPoints(task, due.Add(30*day)))
+
+	task.Points = 10
+	require.Equal(t, 5, awardPoints(task, due.Add(day+day*7/2)))
+	require.Equal(t, 3, awardPoints(task, due.Add(30*day)))
+
+	oneOff := entities.UserTask{OneOff: true}
+	require.Equal(t, 3, awardPoints(oneOff, start))
+}
+
+func TestLeaderboard(t *testing.T) {
+	taskUsecase, storages := setupTestUsecase(t)
+
+	ctx := context.Background()
+	chatID := generateChatID()
+	createTestTask(t, taskUsecase, chatID, "Вынести мусор", "1 день")
+	createTestTask(t, taskUsecase, chatID, "Помыть окна", "1 месяц")
+
+	anna := entities.Member{ID: 1, Name: "Аня"}
+	petya := entities.Member{ID: 2, Name: "Петя"}
+	_, _, err := taskUsecase.DoneTask(ctx, chatID, anna, "1 вчера")
+	require.NoError(t, err)
+	_, _, err = taskUsecase.DoneTask(ctx, chatID, petya, "2")
+	require.NoError(t, err)
+	_, err = storages.history.AddRecord(ctx, entities.HistoryRecord{
+		ChatID:   chatID,
+		TaskID:   1,
+		Kind:     entities.HistoryCompleted,
+		DoneAt:   time.Now().Add(-30 * 24 * time.Hour),
+		UserID:   anna.ID,
+		UserName: anna.Name,
+		Points:   10,
+	})
+	require.NoError(t, err)
+
+	board, err := taskUsecase.GetLeaderboard(ctx, chatID)
+	require.NoError(t, err)
+	require.Equal(t, []entities.MemberPoints{{Member: petya, Points: 5}, {Member: anna, Points: 1}}, board.Week)
+	require.Equal(t, []entities.MemberPoints{{Member: anna, Points: 11}, {Member: petya, Points: 5}}, board.AllTime)
+}
@@ -0,0 +1,466 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"house-timer/internal/pkg/entities"
+	"house-timer/internal/pkg/transfer"
+	"house-timer/pkg/regularity"
+)
+
+// ExportChat collects tasks, settings and history of the chat
+func (t *TaskUsecase) ExportChat(ctx context.Context, chatID int64) (entities.ChatExport, error) {
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return entities.ChatExport{}, errors.Join(ErrGetTasks, err)
+	}
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return entities.ChatExport{}, errors.Join(ErrGetHistory, err)
+	}
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return entities.ChatExport{}, errors.Join(ErrGetChat, err)
+	}
+
+	export := entities.ChatExport{
+		Version:    transfer.Version,
+		ExportedAt: time.Now().UTC(),
+		Settings:   exportSettings(chat),
+		Tasks:      []entities.ExportedTask{},
+		History:    []entities.ExportedRecord{},
+	}
+	names := map[int64]string{}
+	for _, task := range tasks {
+		names[task.ID] = task.Name
+	}
+	exported := map[int64]bool{}
+	for _, task := range tasks {
+		tags := task.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		season := regularity.FormatWindows(task.Season)
+		checklist, err := t.checklistTexts(ctx, task.ID)
+		if err != nil {
+			return entities.ChatExport{}, err
+		}
+		// a followed task that is deleted is not exported, the task is exported as independent
+		after := names[task.AfterTaskID]
+		exportedTask := entities.ExportedTask{
+			ID:                 task.ID,
+			Name:               task.Name,
+			RegularitySeconds:  int64(task.Regularity.Seconds()),
+			LastReminded:       task.LastReminded.UTC(),
+			RemindAfterSeconds: int64(task.RemindAfter.Seconds()),
+			OneOff:             task.OneOff,
+			Notes:              &task.Notes,
+			Category:           &task.Category,
+			Tags:               &tags,
+			Threshold:          &task.Threshold,
+			CounterUnit:        task.CounterUnit,
+			Counter:            task.Counter,
+			Season:             &season,
+			Points:             &task.Points,
+			Anchor:             &task.Anchor,
+			PausedAt:           exportedTime(task.PausedAt),
+			RequiresProof:      &task.RequiresProof,
+			Checklist:          &checklist,
+			After:              &after,
+			AfterDelaySeconds:  int64(task.AfterDelay.Seconds()),
+			ChainedAt:          exportedTime(task.ChainedAt),
+			CounterReachedAt:   exportedTime(task.CounterReachedAt),
+		}
+		if !task.DueAt.IsZero() {
+			dueAt := task.DueAt.UTC()
+			exportedTask.DueAt = &dueAt
+		}
+		if !task.StartAt.IsZero() {
+			exportedTask.AnchorAt = exportedTime(task.StartAt)
+		}
+		export.Tasks = append(export.Tasks, exportedTask)
+		exported[task.ID] = true
+	}
+	for _, record := range history {
+		// history of deleted tasks is not exported
+		if !exported[record.TaskID] {
+			continue
+		}
+		export.History = append(export.History, entities.ExportedRecord{
+			TaskID:   record.TaskID,
+			Kind:     record.Kind,
+			DoneAt:   record.DoneAt.UTC(),
+			UserID:   record.UserID,
+			UserName: record.UserName,
+			Points:   record.Points,
+		})
+	}
+	return export, nil
+}
+
+// exportedTime is always set so that a zero time clears the field on import
+func exportedTime(at time.Time) *time.Time {
+	if at.IsZero() {
+		return &time.Time{}
+	}
+	utc := at.UTC()
+	return &utc
+}
+
+func exportSettings(chat entities.Chat) entities.ExportedSettings {
+	vacation := entities.ExportedVacation{}
+	if !chat.VacationFrom.IsZero() {
+		vacation = entities.ExportedVacation{From: chat.VacationFrom.UTC(), Until: chat.VacationUntil.UTC()}
+	}
+	return entities.ExportedSettings{
+		ICalToken: chat.ICalToken,
+		Vacation:  &vacation,
+		Digest: &entities.ExportedDigest{
+			Period:  chat.Digest.Period,
+			Hour:    chat.Digest.Hour,
+			Weekday: chat.Digest.Weekday,
+			Only:    chat.Digest.Only,
+		},
+		Quiet: &entities.ExportedQuiet{
+			FromSeconds:         int64(chat.Quiet.From.Seconds()),
+			UntilSeconds:        int64(chat.Quiet.Until.Seconds()),
+			WeekendUntilSeconds: int64(chat.Quiet.WeekendUntil.Seconds()),
+			OffsetSeconds:       int64(chat.Quiet.Offset.Seconds()),
+		},
+	}
+}
+
+// checklistTexts returns the items of the checklist, not nil for a task without one
+func (t *TaskUsecase) checklistTexts(ctx context.Context, taskID int64) ([]string, error) {
+	items, err := t.ts.GetChecklist(ctx, taskID)
+	if err != nil {
+		return nil, errors.Join(ErrGetChecklist, err)
+	}
+	texts := []string{}
+	for _, item := range items {
+		texts = append(texts, item.Text)
+	}
+	return texts, nil
+}
+
+func (t *TaskUsecase) StartImport(ctx context.Context, chatID int64) error {
+	_, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err == nil {
+		return ErrEventCollision
+	}
+	_, err = t.tes.CreateTaskEvent(ctx, chatID, entities.TaskImportEvent, entities.TaskImportWaitFile)
+	if err != nil {
+		return errors.Join(ErrCreateTaskEvent, err)
+	}
+	return nil
+}
+
+type historyKey struct {
+	taskID int64
+	kind   entities.HistoryKind
+	doneAt int64
+}
+
+// importPlan matches export with chat tasks by name
+type importPlan struct {
+	diff entities.ImportDiff
+	// existing maps export task id to chat task
+	existing map[int64]entities.UserTask
+	changed  map[int64]bool
+	history  map[historyKey]bool
+}
+
+func (t *TaskUsecase) planImport(ctx context.Context, chatID int64, export entities.ChatExport) (importPlan, error) {
+	if err := transfer.Validate(export); err != nil {
+		return importPlan{}, errors.Join(ErrInvalidImport, err)
+	}
+	tasks, err := t.ts.GetTasksForChat(ctx, chatID)
+	if err != nil {
+		return importPlan{}, errors.Join(ErrGetTasks, err)
+	}
+	history, err := t.hs.GetChatHistory(ctx, chatID)
+	if err != nil {
+		return importPlan{}, errors.Join(ErrGetHistory, err)
+	}
+
+	byName := map[string]entities.UserTask{}
+	names := map[int64]string{}
+	for _, task := range tasks {
+		byName[entities.NormalizeName(task.Name)] = task
+		names[task.ID] = task.Name
+	}
+	plan := importPlan{
+		existing: map[int64]entities.UserTask{},
+		changed:  map[int64]bool{},
+		history:  map[historyKey]bool{},
+	}
+	for _, record := range history {
+		plan.history[historyKey{record.TaskID, record.Kind, record.DoneAt.Unix()}] = true
+	}
+	for _, imported := range export.Tasks {
+		task, ok := byName[entities.NormalizeName(imported.Name)]
+		if !ok {
+			plan.diff.Created = append(plan.diff.Created, imported.Name)
+			continue
+		}
+		plan.existing[imported.ID] = task
+		checklist, err := t.checklistTexts(ctx, task.ID)
+		if err != nil {
+			return importPlan{}, err
+		}
+		if importChanges(task, names[task.AfterTaskID], checklist, imported) {
+			plan.changed[imported.ID] = true
+			plan.diff.Updated = append(plan.diff.Updated, task.Name)
+		} else {
+			plan.diff.Unchanged = append(plan.diff.Unchanged, task.Name)
+		}
+	}
+	for _, record := range export.History {
+		task, ok := plan.existing[record.TaskID]
+		if ok && plan.history[historyKey{task.ID, record.Kind, record.DoneAt.Unix()}] {
+			continue
+		}
+		plan.diff.NewHistory++
+	}
+	return plan, nil
+}
+
+// PreviewImport validates export and remembers fileRef to apply it after confirmation
+func (t *TaskUsecase) PreviewImport(ctx context.Context, chatID int64, fileRef string, export entities.ChatExport) (entities.ImportDiff, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.ImportDiff{}, err
+	}
+	if currentEvent.Type != entities.TaskImportEvent {
+		return entities.ImportDiff{}, ErrBadTaskEvent
+	}
+	plan, err := t.planImport(ctx, chatID, export)
+	if err != nil {
+		return entities.ImportDiff{}, err
+	}
+	err = t.tes.SetPayload(ctx, currentEvent.ID, fileRef)
+	if err != nil {
+		return entities.ImportDiff{}, errors.Join(ErrUpdateTaskStep, err)
+	}
+	err = t.tes.UpdateStep(ctx, chatID, entities.TaskImportConfirm)
+	if err != nil {
+		return entities.ImportDiff{}, errors.Join(ErrUpdateTaskStep, err)
+	}
+	return plan.diff, nil
+}
+
+// ImportFileRef returns the file given to PreviewImport
+func (t *TaskUsecase) ImportFileRef(ctx context.Context, chatID int64) (string, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+	if currentEvent.Step != entities.TaskImportConfirm {
+		return "", ErrBadTaskEvent
+	}
+	return currentEvent.Payload, nil
+}
+
+// importChanges reports whether the imported task differs from the chat task,
+// after is the name of the task it follows and checklist are its items
+func importChanges(task entities.UserTask, after string, checklist []string, imported entities.ExportedTask) bool {
+	return int64(task.Regularity.Seconds()) != imported.RegularitySeconds ||
+		task.LastReminded.Unix() != imported.LastReminded.Unix() ||
+		int64(task.RemindAfter.Seconds()) != imported.RemindAfterSeconds ||
+		task.OneOff != imported.OneOff ||
+		task.DueAt.Unix() != importedTime(imported.DueAt).Unix() ||
+		(imported.Notes != nil && task.Notes != *imported.Notes) ||
+		(imported.Category != nil && task.Category != *imported.Category) ||
+		(imported.Tags != nil && !slices.Equal(task.Tags, *imported.Tags)) ||
+		(imported.Threshold != nil && (task.Threshold != *imported.Threshold ||
+			task.CounterUnit != imported.CounterUnit || task.Counter != imported.Counter ||
+			timeChanged(task.CounterReachedAt, imported.CounterReachedAt))) ||
+		(imported.Season != nil && regularity.FormatWindows(task.Season) != *imported.Season) ||
+		(imported.Points != nil && task.Points != *imported.Points) ||
+		(imported.Anchor != nil && (task.Anchor != *imported.Anchor || timeChanged(task.StartAt, imported.AnchorAt))) ||
+		timeChanged(task.PausedAt, imported.PausedAt) ||
+		(imported.RequiresProof != nil && task.RequiresProof != *imported.RequiresProof) ||
+		(imported.Checklist != nil && !slices.Equal(checklist, *imported.Checklist)) ||
+		(imported.After != nil && (entities.NormalizeName(after) != entities.NormalizeName(*imported.After) ||
+			int64(task.AfterDelay.Seconds()) != imported.AfterDelaySeconds ||
+			timeChanged(task.ChainedAt, imported.ChainedAt)))
+}
+
+// importedTime returns zero time for a missing one
+func importedTime(at *time.Time) time.Time {
+	if at == nil {
+		return time.Time{}
+	}
+	return *at
+}
+
+// timeChanged reports whether the imported time is given and differs from the current one
+func timeChanged(current time.Time, imported *time.Time) bool {
+	return imported != nil && current.Unix() != imported.Unix()
+}
+
+// importedUpdate sets every field of the task to the imported value, zero taskID is for a new task
+func importedUpdate(taskID int64, imported entities.ExportedTask) (entities.TaskUpdate, error) {
+	every := time.Duration(imported.RegularitySeconds) * time.Second
+	remindAfter := time.Duration(imported.RemindAfterSeconds) * time.Second
+	dueAt := importedTime(imported.DueAt)
+	update := entities.TaskUpdate{
+		TaskID:       taskID,
+		Regularity:   &every,
+		LastReminded: &imported.LastReminded,
+		RemindAfter:  &remindAfter,
+		DueAt:        &dueAt,
+		OneOff:       &imported.OneOff,
+	}
+	if taskID == 0 {
+		update.Name = &imported.Name
+	}
+	// csv has no notes and categories, missing ones keep the current values
+	update.Notes = imported.Notes
+	update.Category = imported.Category
+	update.Tags = imported.Tags
+	if imported.Threshold != nil {
+		reachedAt := importedTime(imported.CounterReachedAt)
+		update.Threshold = imported.Threshold
+		update.CounterUnit = &imported.CounterUnit
+		update.Counter = &imported.Counter
+		update.CounterReachedAt = &reachedAt
+	}
+	if imported.Season != nil {
+		season, err := regularity.ParseWindows(*imported.Season)
+		if err != nil {
+			return entities.TaskUpdate{}, errors.Join(ErrParseSeason, err)
+		}
+		update.Season = &season
+	}
+	update.Points = imported.Points
+	if imported.Anchor != nil {
+		update.Anchor = imported.Anchor
+		update.StartAt = imported.AnchorAt
+	}
+	update.PausedAt = imported.PausedAt
+	update.RequiresProof = imported.RequiresProof
+	// the task followed is linked by the storage once every imported task has an id
+	if imported.After != nil {
+		delay := time.Duration(imported.AfterDelaySeconds) * time.Second
+		chainedAt := importedTime(imported.ChainedAt)
+		update.AfterDelay = &delay
+		update.ChainedAt = &chainedAt
+	}
+	return update, nil
+}
+
+// importSettings sets the chat settings given in the export
+func importSettings(batch *entities.ImportBatch, settings entities.ExportedSettings) {
+	batch.Vacation = settings.Vacation
+	if digest := settings.Digest; digest != nil {
+		batch.Digest = &entities.DigestSettings{
+			Period:  digest.Period,
+			Hour:    digest.Hour,
+			Weekday: digest.Weekday,
+			Only:    digest.Only,
+		}
+	}
+	if quiet := settings.Quiet; quiet != nil {
+		batch.Quiet = &entities.QuietHours{
+			From:         time.Duration(quiet.FromSeconds) * time.Second,
+			Until:        time.Duration(quiet.UntilSeconds) * time.Second,
+			WeekendUntil: time.Duration(quiet.WeekendUntilSeconds) * time.Second,
+			Offset:       time.Duration(quiet.OffsetSeconds) * time.Second,
+		}
+	}
+}
+
+// ApplyImport creates missing tasks, updates changed ones and adds new history in one transaction,
+// chat tasks missing in the export are left as is
+func (t *TaskUsecase) ApplyImport(ctx context.Context, chatID int64, export entities.ChatExport) (entities.ImportDiff, error) {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return entities.ImportDiff{}, err
+	}
+	if currentEvent.Step != entities.TaskImportConfirm {
+		return entities.ImportDiff{}, ErrBadTaskEvent
+	}
+	plan, err := t.planImport(ctx, chatID, export)
+	if err != nil {
+		return entities.ImportDiff{}, err
+	}
+
+	batch := entities.ImportBatch{ChatID: chatID}
+	chat, err := t.cs.GetChat(ctx, chatID)
+	if err != nil {
+		return entities.ImportDiff{}, errors.Join(ErrGetChat, err)
+	}
+	if chat.ICalToken == "" {
+		batch.ICalToken = export.Settings.ICalToken
+	}
+	importSettings(&batch, export.Settings)
+	// the batch has the tasks in the order of the export, they are found by id and by name
+	positions := map[int64]int{}
+	byName := map[string]int{}
+	for i, imported := range export.Tasks {
+		positions[imported.ID] = i
+		byName[entities.NormalizeName(imported.Name)] = i
+	}
+	for _, imported := range export.Tasks {
+		task, ok := plan.existing[imported.ID]
+		importedTask := entities.ImportedTask{Update: entities.TaskUpdate{TaskID: task.ID}}
+		if !ok || plan.changed[imported.ID] {
+			importedTask.Update, err = importedUpdate(task.ID, imported)
+			if err != nil {
+				return entities.ImportDiff{}, errors.Join(ErrImport, err)
+			}
+			importedTask.Checklist = imported.Checklist
+			if imported.After != nil {
+				after := -1
+				if *imported.After != "" {
+					after = byName[entities.NormalizeName(*imported.After)]
+				}
+				importedTask.After = &after
+			}
+		}
+		batch.Tasks = append(batch.Tasks, importedTask)
+	}
+	for _, record := range export.History {
+		imported := &batch.Tasks[positions[record.TaskID]]
+		taskID := imported.Update.TaskID
+		if taskID != 0 && plan.history[historyKey{taskID, record.Kind, record.DoneAt.Unix()}] {
+			continue
+		}
+		imported.History = append(imported.History, entities.HistoryRecord{
+			Kind:     record.Kind,
+			DoneAt:   record.DoneAt,
+			UserID:   record.UserID,
+			UserName: record.UserName,
+			Points:   record.Points,
+		})
+	}
+	err = t.ts.ImportChat(ctx, batch)
+	if err != nil {
+		return entities.ImportDiff{}, errors.Join(ErrImport, err)
+	}
+
+	err = t.tes.DeleteEvent(ctx, currentEvent.ID)
+	if err != nil {
+		return entities.ImportDiff{}, errors.Join(ErrDeleteEvent, err)
+	}
+	return plan.diff, nil
+}
+
+func (t *TaskUsecase) StopImport(ctx context.Context, chatID int64) error {
+	currentEvent, err := t.tes.GetCurrentTaskEvent(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if currentEvent.Type != entities.TaskImportEvent {
+		return ErrBadTaskEvent
+	}
+	err = t.tes.DeleteEvent(ctx, currentEvent.ID)
+	if err != nil {
+		return errors.Join(ErrDeleteEvent, err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE TaskHistory (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+    DeletedAt INTEGER,
+
+    ChatID INTEGER NOT NULL,
+    TaskID INTEGER NOT NULL,
+
+    Kind VARCHAR(32) NOT NULL,
+    DoneAt INTEGER NOT NULL
+);
+
+ALTER TABLE TaskEvents
+ADD Payload TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+DROP TABLE IF EXISTS TaskHistory;
+ALTER TABLE TaskEvents
+    DROP COLUMN Payload;
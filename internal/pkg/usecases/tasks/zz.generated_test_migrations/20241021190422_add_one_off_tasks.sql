@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD OneOff INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Tasks
+ADD DueAt INTEGER;
+
+ALTER TABLE Tasks
+ADD ArchivedAt INTEGER;
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN OneOff;
+ALTER TABLE Tasks
+    DROP COLUMN DueAt;
+ALTER TABLE Tasks
+    DROP COLUMN ArchivedAt;
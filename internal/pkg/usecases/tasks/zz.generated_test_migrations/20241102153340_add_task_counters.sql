@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Threshold REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE Tasks
+ADD CounterUnit TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE Tasks
+ADD Counter REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE Tasks
+ADD CounterReachedAt INTEGER;
+
+CREATE TABLE TaskReadings (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+    DeletedAt INTEGER,
+
+    TaskID INTEGER NOT NULL,
+    Value REAL NOT NULL,
+    LoggedAt INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS TaskReadings;
+ALTER TABLE Tasks
+    DROP COLUMN CounterReachedAt;
+ALTER TABLE Tasks
+    DROP COLUMN Counter;
+ALTER TABLE Tasks
+    DROP COLUMN CounterUnit;
+ALTER TABLE Tasks
+    DROP COLUMN Threshold;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE TaskHistory
+ADD UserID INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE TaskHistory
+ADD UserName TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE TaskSnoozes (
+    ID INTEGER PRIMARY KEY AUTOINCREMENT,
+    CreatedAt INTEGER,
+
+    ChatID INTEGER NOT NULL,
+    TaskID INTEGER NOT NULL,
+
+    SnoozedAt INTEGER NOT NULL,
+    UserID INTEGER NOT NULL DEFAULT 0,
+    UserName TEXT NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS TaskSnoozes;
+ALTER TABLE TaskHistory
+    DROP COLUMN UserName;
+ALTER TABLE TaskHistory
+    DROP COLUMN UserID;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Anchor VARCHAR(16) NOT NULL DEFAULT 'completion';
+
+ALTER TABLE Tasks
+ADD AnchorAt INTEGER;
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN Anchor;
+ALTER TABLE Tasks
+    DROP COLUMN AnchorAt;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD RequiresProof INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE TaskHistory
+ADD ProofFileID TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE TaskHistory
+    DROP COLUMN ProofFileID;
+ALTER TABLE Tasks
+    DROP COLUMN RequiresProof;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD AfterTaskID INTEGER;
+
+ALTER TABLE Tasks
+ADD AfterDelay INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Tasks
+ADD ChainedAt INTEGER;
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN ChainedAt;
+ALTER TABLE Tasks
+    DROP COLUMN AfterDelay;
+ALTER TABLE Tasks
+    DROP COLUMN AfterTaskID;
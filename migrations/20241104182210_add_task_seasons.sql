@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD Season TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN Season;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE Chats
+ADD QuietFrom INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD QuietUntil INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD QuietWeekendUntil INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Chats
+ADD UTCOffset INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE Chats
+    DROP COLUMN UTCOffset;
+ALTER TABLE Chats
+    DROP COLUMN QuietWeekendUntil;
+ALTER TABLE Chats
+    DROP COLUMN QuietUntil;
+ALTER TABLE Chats
+    DROP COLUMN QuietFrom;
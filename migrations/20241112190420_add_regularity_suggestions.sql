@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE Tasks
+ADD SuggestedRegularity INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE Tasks
+ADD SuggestedAt INTEGER;
+
+-- +goose Down
+ALTER TABLE Tasks
+    DROP COLUMN SuggestedAt;
+ALTER TABLE Tasks
+    DROP COLUMN SuggestedRegularity;
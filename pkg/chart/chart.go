@@ -0,0 +1,219 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+const (
+	Width = 800
+	// margin leaves room for axis labels
+	margin         = 40
+	timelineHeight = 200
+	barsHeight     = 260
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	axis       = color.RGBA{0x60, 0x60, 0x60, 0xff}
+	grid       = color.RGBA{0xe4, 0xe4, 0xe4, 0xff}
+	dueColor   = color.RGBA{0x9e, 0x9e, 0x9e, 0xff}
+	onTime     = color.RGBA{0x43, 0xa0, 0x47, 0xff}
+	late       = color.RGBA{0xe5, 0x39, 0x35, 0xff}
+)
+
+// Palette colors series of bar charts in order, the caption refers to them
+var Palette = []color.RGBA{
+	{0x1e, 0x88, 0xe5, 0xff},
+	{0xfb, 0x8c, 0x00, 0xff},
+	{0x43, 0xa0, 0x47, 0xff},
+	{0xe5, 0x39, 0x35, 0xff},
+	{0x8e, 0x24, 0xaa, 0xff},
+	{0xfd, 0xd8, 0x35, 0xff},
+}
+
+// Occurrence is a due date of a task and how it was closed
+type Occurrence struct {
+	Due  time.Time
+	Done time.Time
+	// Skipped occurrences are drawn without a completion mark
+	Skipped bool
+	Late    bool
+}
+
+// Timeline shows due dates on the upper line and completions on the lower one
+type Timeline struct {
+	From        time.Time
+	To          time.Time
+	Occurrences []Occurrence
+}
+
+// Bars are grouped per month, Values[series][month]
+type Bars struct {
+	Months []time.Time
+	Values [][]int
+}
+
+func fill(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// line draws a segment two pixels wide
+func line(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		fill(img, image.Rect(x0, y0, x0+2, y0+2), c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func disc(img draw.Image, cx, cy, r int, c color.Color) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.Set(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// x maps t to the horizontal position between the margins
+func (tl Timeline) x(t time.Time) int {
+	span := tl.To.Sub(tl.From)
+	if span <= 0 {
+		return margin
+	}
+	return margin + int(float64(Width-2*margin)*float64(t.Sub(tl.From))/float64(span))
+}
+
+func (tl Timeline) draw(img draw.Image, top int) {
+	dueY, doneY := top+60, top+140
+	for month := time.Date(tl.From.Year(), tl.From.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(tl.To); month = month.AddDate(0, 1, 0) {
+		if month.Before(tl.From) {
+			continue
+		}
+		x := tl.x(month)
+		fill(img, image.Rect(x, top+30, x+1, doneY+20), grid)
+		label := month.Format("01.06")
+		drawText(img, x-textWidth(label)/2, doneY+30, label, axis)
+	}
+	fill(img, image.Rect(margin, dueY, Width-margin, dueY+1), axis)
+	fill(img, image.Rect(margin, doneY, Width-margin, doneY+1), axis)
+	for _, o := range tl.Occurrences {
+		dueX := tl.x(o.Due)
+		fill(img, image.Rect(dueX-1, dueY-8, dueX+1, dueY+8), dueColor)
+		if o.Skipped || o.Done.IsZero() {
+			continue
+		}
+		c := onTime
+		if o.Late {
+			c = late
+		}
+		doneX := tl.x(o.Done)
+		line(img, dueX, dueY, doneX, doneY, c)
+		disc(img, doneX, doneY, 6, c)
+	}
+}
+
+func (b Bars) max() int {
+	res := 1
+	for _, series := range b.Values {
+		for _, v := range series {
+			res = max(res, v)
+		}
+	}
+	return res
+}
+
+func (b Bars) draw(img draw.Image, top int) {
+	bottom := top + barsHeight - margin
+	height := bottom - top - 20
+	maxValue := b.max()
+	// a few round ticks on the value axis
+	step := max(1, (maxValue+3)/4)
+	for v := 0; v <= maxValue; v += step {
+		y := bottom - height*v/maxValue
+		fill(img, image.Rect(margin, y, Width-margin, y+1), grid)
+		label := fmt.Sprint(v)
+		drawText(img, margin-6-textWidth(label), y-glyphHeight*fontScale/2, label, axis)
+	}
+	fill(img, image.Rect(margin, bottom, Width-margin, bottom+1), axis)
+	if len(b.Months) == 0 {
+		return
+	}
+	groupWidth := (Width - 2*margin) / len(b.Months)
+	barWidth := max(2, (groupWidth-10)/max(1, len(b.Values)))
+	for m, month := range b.Months {
+		left := margin + m*groupWidth + 5
+		for s, series := range b.Values {
+			if m >= len(series) || series[m] == 0 {
+				continue
+			}
+			x := left + s*barWidth
+			y := bottom - height*series[m]/maxValue
+			fill(img, image.Rect(x, y, x+barWidth-1, bottom), Palette[s%len(Palette)])
+		}
+		label := month.Format("01.06")
+		drawText(img, margin+m*groupWidth+(groupWidth-textWidth(label))/2, bottom+10, label, axis)
+	}
+}
+
+// Render draws the timeline above the bars, either of them may be nil
+func Render(timeline *Timeline, bars *Bars) *image.RGBA {
+	height := 0
+	if timeline != nil {
+		height += timelineHeight
+	}
+	if bars != nil {
+		height += barsHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, Width, max(height, 1)))
+	fill(img, img.Bounds(), background)
+	top := 0
+	if timeline != nil {
+		timeline.draw(img, top)
+		top += timelineHeight
+	}
+	if bars != nil {
+		bars.draw(img, top)
+	}
+	return img
+}
+
+// EncodePNG renders the chart to png
+func EncodePNG(timeline *Timeline, bars *Bars) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, Render(timeline, bars)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextWidth(t *testing.T) {
+	cases := map[string]int{
+		"":      0,
+		"1":     6,
+		"10.24": 38,
+	}
+	for key, value := range cases {
+		assert.Equal(t, value, textWidth(key), key)
+	}
+}
+
+func TestRender(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	timeline := &Timeline{
+		From: from,
+		To:   from.Add(100 * day),
+		Occurrences: []Occurrence{
+			{Due: from.Add(10 * day), Done: from.Add(10 * day)},
+			{Due: from.Add(50 * day), Done: from.Add(60 * day), Late: true},
+			{Due: from.Add(70 * day), Done: from.Add(70 * day), Skipped: true},
+			{Due: from.Add(90 * day)},
+		},
+	}
+	bars := &Bars{
+		Months: []time.Time{from, from.AddDate(0, 1, 0), from.AddDate(0, 2, 0)},
+		Values: [][]int{{3, 0, 5}, {1, 2, 0}},
+	}
+
+	img := Render(timeline, bars)
+	require.Equal(t, Width, img.Bounds().Dx())
+	require.Equal(t, timelineHeight+barsHeight, img.Bounds().Dy())
+	// completion marks on the lower line
+	assert.Equal(t, onTime, img.RGBAAt(timeline.x(from.Add(10*day)), 140))
+	assert.Equal(t, late, img.RGBAAt(timeline.x(from.Add(60*day)), 140))
+	assert.Equal(t, background, img.RGBAAt(timeline.x(from.Add(70*day))+4, 140-4))
+	// the highest bar of the first series is in the third month
+	groupWidth := (Width - 2*margin) / 3
+	bottom := timelineHeight + barsHeight - margin
+	assert.Equal(t, Palette[0], img.RGBAAt(margin+2*groupWidth+6, bottom-1))
+	assert.Equal(t, Palette[1], img.RGBAAt(margin+groupWidth+5+(groupWidth-10)/2+1, bottom-1))
+
+	data, err := EncodePNG(nil, bars)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, barsHeight, decoded.Bounds().Dy())
+}
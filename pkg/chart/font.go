@@ -0,0 +1,59 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// glyphs is a 3x5 pixel font for axis labels, names go to the caption of the image
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	// fontScale makes glyphs readable on phones
+	fontScale = 2
+)
+
+// textWidth returns the width of s drawn by drawText
+func textWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+1) - 1) * fontScale
+}
+
+// drawText draws s with the top left corner at x, y, unknown runes are left blank
+func drawText(img draw.Image, x, y int, s string, c color.Color) {
+	for _, r := range s {
+		glyph, ok := glyphs[r]
+		if ok {
+			for row, line := range glyph {
+				for col, pixel := range line {
+					if pixel != '#' {
+						continue
+					}
+					rect := image.Rect(x+col*fontScale, y+row*fontScale, x+(col+1)*fontScale, y+(row+1)*fontScale)
+					draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * fontScale
+	}
+}
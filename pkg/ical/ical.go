@@ -0,0 +1,113 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	timeFormat = "20060102T150405Z"
+	// maxLineLength is the limit of octets per content line, longer lines are folded
+	maxLineLength = 75
+)
+
+// Event is a calendar event, recurring if Every is not zero
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	Duration    time.Duration
+	Every       time.Duration
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// RRule returns the recurrence rule for events repeated every d,
+// months and years are written as days as they have fixed length here
+func RRule(every time.Duration) string {
+	every = every.Round(time.Minute)
+	switch {
+	case every%(7*24*time.Hour) == 0:
+		return fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", every/(7*24*time.Hour))
+	case every%(24*time.Hour) == 0:
+		return fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", every/(24*time.Hour))
+	case every%time.Hour == 0:
+		return fmt.Sprintf("FREQ=HOURLY;INTERVAL=%d", every/time.Hour)
+	default:
+		return fmt.Sprintf("FREQ=MINUTELY;INTERVAL=%d", every/time.Minute)
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("PT%dH", d/time.Hour)
+	}
+	return fmt.Sprintf("PT%dM", d/time.Minute)
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes content line folding it by maxLineLength octets without splitting utf-8 runes
+func (w *writer) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space
+		limit = maxLineLength - 1
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// Marshal returns the calendar in iCalendar (RFC 5545) format
+func (c Calendar) Marshal(now time.Time) []byte {
+	w := writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//house-timer//RU")
+	w.line("CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", textEscaper.Replace(c.Name))
+	}
+	for _, e := range c.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", e.UID)
+		w.line("DTSTAMP", now.UTC().Format(timeFormat))
+		w.line("DTSTART", e.Start.UTC().Format(timeFormat))
+		if e.Duration > 0 {
+			w.line("DURATION", formatDuration(e.Duration))
+		}
+		w.line("SUMMARY", textEscaper.Replace(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION", textEscaper.Replace(e.Description))
+		}
+		if e.Every > 0 {
+			w.line("RRULE", RRule(e.Every))
+		}
+		w.line("END", "VEVENT")
+	}
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
@@ -0,0 +1,58 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRRule(t *testing.T) {
+	cases := map[time.Duration]string{
+		24 * time.Hour:      "FREQ=DAILY;INTERVAL=1",
+		14 * 24 * time.Hour: "FREQ=WEEKLY;INTERVAL=2",
+		30 * 24 * time.Hour: "FREQ=DAILY;INTERVAL=30",
+		12 * time.Hour:      "FREQ=HOURLY;INTERVAL=12",
+		90 * time.Minute:    "FREQ=MINUTELY;INTERVAL=90",
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			assert.Equal(t, value, RRule(key))
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		Name: "Дом",
+		Events: []Event{{
+			UID:      "task-1@house-timer",
+			Summary:  "Полить цветы, кактус; фикус",
+			Start:    time.Date(2024, 10, 3, 15, 0, 0, 0, time.UTC),
+			Duration: 30 * time.Minute,
+			Every:    7 * 24 * time.Hour,
+		}},
+	}
+	res := string(cal.Marshal(now))
+	assert.True(t, strings.HasPrefix(res, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(res, "END:VCALENDAR\r\n"))
+	assert.Contains(t, res, "DTSTART:20241003T150000Z\r\n")
+	assert.Contains(t, res, "DTSTAMP:20241001T120000Z\r\n")
+	assert.Contains(t, res, "DURATION:PT30M\r\n")
+	assert.Contains(t, res, "RRULE:FREQ=WEEKLY;INTERVAL=1\r\n")
+	assert.Contains(t, res, `SUMMARY:Полить цветы\, кактус\; фикус`)
+}
+
+func TestFolding(t *testing.T) {
+	cal := Calendar{Events: []Event{{
+		UID:     "1",
+		Summary: strings.Repeat("очень длинное название задачи ", 10),
+		Start:   time.Now(),
+	}}}
+	for _, line := range strings.Split(string(cal.Marshal(time.Now())), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+	}
+}
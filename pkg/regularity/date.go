@@ -0,0 +1,213 @@
+package regularity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var datePrepositions = map[string]bool{
+	"до": true,
+	"по": true,
+	"в":  true,
+}
+
+// relativeDays are words for days counted back from today
+var relativeDays = map[string]int{
+	"сейчас":    0,
+	"сегодня":   0,
+	"вчера":     1,
+	"позавчера": 2,
+}
+
+const agoWord = "назад"
+
+var monthNames = map[string]time.Month{
+	"января":   time.January,
+	"февраля":  time.February,
+	"марта":    time.March,
+	"апреля":   time.April,
+	"мая":      time.May,
+	"июня":     time.June,
+	"июля":     time.July,
+	"августа":  time.August,
+	"сентября": time.September,
+	"октября":  time.October,
+	"ноября":   time.November,
+	"декабря":  time.December,
+}
+
+func dateWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(strings.ReplaceAll(strings.ToLower(s), "ё", "е")) {
+		if !datePrepositions[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// dateIn returns the date in the year, time.Date normalizes 31.02 to march
+// and 29.02 of a common year to 1 march, such dates are rejected
+func dateIn(year int, month time.Month, dayNum int) (time.Time, error) {
+	date := time.Date(year, month, dayNum, 0, 0, 0, 0, time.UTC)
+	if date.Day() != dayNum || date.Month() != month {
+		return time.Time{}, ErrBadDate
+	}
+	return date, nil
+}
+
+// parseDayMonth reads "25.10", "25/10", "25.10.2024", "25 октября" or "25 октября 2024",
+// year is 0 when not given, then the day is checked against a leap year
+// and callers check it again in the year they choose
+func parseDayMonth(words []string) (int, time.Month, int, error) {
+	var parts []string
+	switch len(words) {
+	case 1:
+		parts = strings.FieldsFunc(words[0], func(r rune) bool {
+			return r == '.' || r == '/'
+		})
+	case 2, 3:
+		month, ok := monthNames[words[1]]
+		if !ok {
+			return 0, 0, 0, ErrBadDate
+		}
+		parts = append([]string{words[0], strconv.Itoa(int(month))}, words[2:]...)
+	}
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, 0, 0, ErrBadDate
+	}
+	nums := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, 0, 0, ErrBadDate
+		}
+		nums = append(nums, n)
+	}
+	year := 0
+	if len(nums) == 3 {
+		year = nums[2]
+		if year < 100 {
+			year += 2000
+		}
+	}
+	dayNum, month := nums[0], time.Month(nums[1])
+	if _, err := dateIn(max(year, 2000), month, dayNum); err != nil {
+		return 0, 0, 0, err
+	}
+	return dayNum, month, year, nil
+}
+
+// ParseClock reads "8", "18:30" or "9:05" as time from midnight,
+// "24" and "24:00" are the end of the day
+func ParseClock(word string) (time.Duration, bool) {
+	hours, minutes, hasMinutes := strings.Cut(word, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, false
+	}
+	m := 0
+	if hasMinutes {
+		m, err = strconv.Atoi(minutes)
+		if err != nil || len(minutes) != 2 || m < 0 || m > 59 {
+			return 0, false
+		}
+	}
+	if h == 24 && m != 0 {
+		return 0, false
+	}
+	return hour(h) + minute(m), true
+}
+
+// parseTimeOfDay reads the time of a moment, minutes are required so that "вчера 18" is not a time
+func parseTimeOfDay(word string) (time.Duration, bool) {
+	if !strings.Contains(word, ":") {
+		return 0, false
+	}
+	clock, ok := ParseClock(word)
+	return clock, ok && clock < day(1)
+}
+
+// ParseFutureDate understands dates like "25.10", "до 25.10", "25.10.2024" and "15 ноября",
+// a date without year is the nearest such day not before today
+func ParseFutureDate(s string, now time.Time) (time.Time, error) {
+	dayNum, month, year, err := parseDayMonth(dateWords(s))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if year != 0 {
+		return dateIn(year, month, dayNum)
+	}
+	today := startOfDay(now)
+	year = today.Year()
+	if time.Date(year, month, dayNum, 0, 0, 0, 0, time.UTC).Before(today) {
+		year++
+	}
+	return dateIn(year, month, dayNum)
+}
+
+func hasRelativeDay(word string) bool {
+	_, ok := relativeDays[word]
+	return ok
+}
+
+// ParsePastDate understands moments like "вчера", "3 дня назад", "12.10", "12 октября",
+// "12.10.2024" and "вчера в 18:30", a date without year is the nearest such day
+// not after today, moments after now are rejected
+func ParsePastDate(s string, now time.Time) (time.Time, error) {
+	words := dateWords(s)
+	var clock time.Duration
+	hasClock := false
+	if len(words) > 0 {
+		clock, hasClock = parseTimeOfDay(words[len(words)-1])
+		if hasClock {
+			words = words[:len(words)-1]
+		}
+	}
+
+	var res time.Time
+	switch {
+	case len(words) == 0 && hasClock:
+		res = now
+	case len(words) == 0:
+		return time.Time{}, ErrBadDate
+	case words[len(words)-1] == agoWord:
+		if hasClock {
+			return time.Time{}, ErrBadDate
+		}
+		dur, err := parseDuration(strings.Join(words[:len(words)-1], " "))
+		if err != nil {
+			return time.Time{}, ErrBadDate
+		}
+		res = now.Add(-dur.Duration)
+	case len(words) == 1 && hasRelativeDay(words[0]):
+		res = now.AddDate(0, 0, -relativeDays[words[0]])
+	default:
+		dayNum, month, year, err := parseDayMonth(words)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if year == 0 {
+			year = now.UTC().Year()
+			if time.Date(year, month, dayNum, 0, 0, 0, 0, time.UTC).After(startOfDay(now)) {
+				year--
+			}
+		}
+		res, err = dateIn(year, month, dayNum)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if hasClock {
+		res = startOfDay(res).Add(clock)
+	}
+	if res.After(now) {
+		return time.Time{}, ErrBadDate
+	}
+	return res, nil
+}
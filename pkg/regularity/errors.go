@@ -1,9 +1,32 @@
 package regularity
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ErrArgCount = errors.New("arg count mismatch (must be 2)")
-	ErrZero     = errors.New("count must be > 0")
-	ErrFirstArg = errors.New("first argument must be nubmer")
+	ErrArgCount       = errors.New("no regularity given")
+	ErrZero           = errors.New("count must be > 0")
+	ErrTooShort       = errors.New("regularity must be at least a day")
+	ErrNumberSequence = errors.New("number must be followed by unit")
+	ErrUnknownUnit    = errors.New("unknown unit")
+	ErrBadDate        = errors.New("bad date")
+	ErrBadSeason      = errors.New("bad season")
 )
+
+// UnknownUnitError is returned for words too far from every known unit,
+// Suggestions holds the nearest units, best first
+type UnknownUnitError struct {
+	Word        string
+	Suggestions []string
+}
+
+func (e *UnknownUnitError) Error() string {
+	return fmt.Sprintf("unknown unit %q, nearest: %s", e.Word, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *UnknownUnitError) Is(target error) bool {
+	return target == ErrUnknownUnit
+}
@@ -0,0 +1,62 @@
+package regularity
+
+import (
+	"fmt"
+	"time"
+)
+
+var formatUnits = []struct {
+	dur time.Duration
+	// one is used after "каждый/каждую", few and many after numbers
+	one, oneAcc, few, many string
+	every                  string
+}{
+	{year(1), "год", "год", "года", "лет", "каждый"},
+	{month(1), "месяц", "месяц", "месяца", "месяцев", "каждый"},
+	{week(1), "неделя", "неделю", "недели", "недель", "каждую"},
+	{day(1), "день", "день", "дня", "дней", "каждый"},
+	{time.Hour, "час", "час", "часа", "часов", "каждый"},
+	{time.Minute, "минута", "минуту", "минуты", "минут", "каждую"},
+}
+
+// pluralize picks the russian form for n: one (1 день), few (2 дня) or many (5 дней)
+func pluralize(n int64, one, few, many string) string {
+	if n%10 == 1 && n%100 != 11 {
+		return one
+	}
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) {
+		return few
+	}
+	return many
+}
+
+// split finds the largest unit dividing d and returns the count of it
+func split(d time.Duration) (int64, int) {
+	d = d.Round(time.Minute)
+	for i, u := range formatUnits {
+		if d%u.dur == 0 {
+			return int64(d / u.dur), i
+		}
+	}
+	return int64(d / time.Minute), len(formatUnits) - 1
+}
+
+// Format returns d in human readable form, e.g. "2 недели"
+func Format(d time.Duration) string {
+	n, i := split(d)
+	u := formatUnits[i]
+	return fmt.Sprintf("%d %s", n, pluralize(n, u.one, u.few, u.many))
+}
+
+// FormatEvery returns d as a regularity, e.g. "каждые 2 недели" or "каждый день"
+func FormatEvery(d time.Duration) string {
+	n, i := split(d)
+	u := formatUnits[i]
+	if n == 1 {
+		return fmt.Sprintf("%s %s", u.every, u.oneAcc)
+	}
+	if n%10 == 1 && n%100 != 11 {
+		return fmt.Sprintf("%s %d %s", u.every, n, u.oneAcc)
+	}
+	return fmt.Sprintf("каждые %d %s", n, pluralize(n, u.one, u.few, u.many))
+}
@@ -0,0 +1,228 @@
+package regularity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Result is a parsed regularity with the confidence of the parse
+type Result struct {
+	Duration time.Duration
+	// Confidence is 1 when every word was recognized exactly
+	// and goes down to 0 for guessed units and bare numbers
+	Confidence float64
+}
+
+// bareNumberConfidence is used when a number is given without unit and days are assumed
+const bareNumberConfidence = 0.5
+
+var numberWords = map[string]float64{
+	"один":         1,
+	"одна":         1,
+	"одну":         1,
+	"одно":         1,
+	"два":          2,
+	"две":          2,
+	"пару":         2,
+	"пара":         2,
+	"три":          3,
+	"четыре":       4,
+	"пять":         5,
+	"шесть":        6,
+	"семь":         7,
+	"восемь":       8,
+	"девять":       9,
+	"десять":       10,
+	"одиннадцать":  11,
+	"двенадцать":   12,
+	"тринадцать":   13,
+	"четырнадцать": 14,
+	"пятнадцать":   15,
+	"двадцать":     20,
+	"тридцать":     30,
+	"сорок":        40,
+	"пол":          0.5,
+	"полтора":      1.5,
+	"полторы":      1.5,
+}
+
+var adverbs = map[string]time.Duration{
+	"ежедневно":   day(1),
+	"каждодневно": day(1),
+	"еженедельно": week(1),
+	"ежемесячно":  month(1),
+	"ежегодно":    year(1),
+}
+
+var fillers = map[string]bool{
+	"каждые":  true,
+	"каждый":  true,
+	"каждую":  true,
+	"каждое":  true,
+	"каждого": true,
+	"каждых":  true,
+	"через":   true,
+	"в":       true,
+	"во":      true,
+	"и":       true,
+	"по":      true,
+}
+
+const halfPrefix = "пол"
+
+// normalize lowercases s, separates numbers glued to words
+// and replaces punctuation with spaces
+func normalize(s string) string {
+	runes := []rune(strings.ReplaceAll(strings.ToLower(s), "ё", "е"))
+	isDecimalPoint := func(i int) bool {
+		return (runes[i] == '.' || runes[i] == ',') &&
+			i > 0 && i+1 < len(runes) &&
+			unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+	}
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case isDecimalPoint(i):
+			b.WriteRune('.')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) &&
+				unicode.IsDigit(r) != unicode.IsDigit(runes[i-1]) {
+				b.WriteRune(' ')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+func exactUnit(word string) (durFunc, bool) {
+	for _, r := range regularitites {
+		if r.word == word {
+			return r.dur, true
+		}
+	}
+	return nil, false
+}
+
+type parser struct {
+	total      time.Duration
+	units      int
+	count      float64
+	hasCount   bool
+	countWord  bool
+	times      float64
+	confidence float64
+}
+
+func (p *parser) setCount(count float64, fromWord bool) error {
+	if p.hasCount {
+		// "двадцать пять"
+		if fromWord && p.countWord && p.count >= 20 && count < 10 {
+			p.count += count
+			return nil
+		}
+		return ErrNumberSequence
+	}
+	p.count = count
+	p.hasCount = true
+	p.countWord = fromWord
+	return nil
+}
+
+func (p *parser) addUnit(unit durFunc, confidence float64) {
+	count := 1.0
+	if p.hasCount {
+		count = p.count
+		p.hasCount = false
+	}
+	p.total += time.Duration(float64(unit(1)) * count)
+	p.units++
+	p.confidence = min(p.confidence, confidence)
+}
+
+func (p *parser) word(w string) error {
+	if n, err := strconv.ParseFloat(w, 64); err == nil {
+		return p.setCount(n, false)
+	}
+	if n, ok := numberWords[w]; ok {
+		return p.setCount(n, true)
+	}
+	if w == "раз" || w == "раза" {
+		// "раз в месяц", "2 раза в неделю"
+		p.times = 1
+		if p.hasCount {
+			p.times = p.count
+			p.hasCount = false
+		}
+		return nil
+	}
+	if fillers[w] {
+		return nil
+	}
+	if dur, ok := adverbs[w]; ok {
+		if p.hasCount {
+			return ErrNumberSequence
+		}
+		p.total += dur
+		p.units++
+		return nil
+	}
+	if unit, ok := exactUnit(w); ok {
+		p.addUnit(unit, 1)
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(w, halfPrefix); ok {
+		if unit, ok := exactUnit(rest); ok {
+			if err := p.setCount(0.5, true); err != nil {
+				return err
+			}
+			p.addUnit(unit, 1)
+			return nil
+		}
+	}
+	unit, similarity, err := nearestDuration(w)
+	if err != nil {
+		return err
+	}
+	p.addUnit(unit, similarity)
+	return nil
+}
+
+// parseDuration reads a duration of any length, "2 часа" included
+func parseDuration(regularityString string) (Result, error) {
+	p := parser{confidence: 1, times: 1}
+	for _, w := range strings.Fields(normalize(regularityString)) {
+		if err := p.word(w); err != nil {
+			return Result{}, err
+		}
+	}
+	if p.hasCount {
+		p.addUnit(day, bareNumberConfidence)
+	}
+	if p.units == 0 {
+		return Result{}, ErrArgCount
+	}
+	if p.total <= 0 || p.times <= 0 {
+		return Result{}, ErrZero
+	}
+	p.total = time.Duration(float64(p.total) / p.times)
+	return Result{Duration: p.total, Confidence: p.confidence}, nil
+}
+
+// Parse understands regularities like "2 дня", "каждые 2 недели", "раз в месяц",
+// "две недели", "2недели", "1.5 месяца", "ежедневно", "1 неделя 3 дня" and "1 день 12 часов",
+// reminders are sent once a day so regularities under a day are rejected with ErrTooShort
+func Parse(regularityString string) (Result, error) {
+	res, err := parseDuration(regularityString)
+	if err != nil {
+		return Result{}, err
+	}
+	if res.Duration < day(1) {
+		return Result{}, ErrTooShort
+	}
+	return res, nil
+}
@@ -1,13 +1,20 @@
 package regularity
 
 import (
-	"strconv"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/agnivade/levenshtein"
 )
 
+func minute(count int) time.Duration {
+	return time.Duration(count) * time.Minute
+}
+
+func hour(count int) time.Duration {
+	return time.Duration(count) * time.Hour
+}
+
 func day(count int) time.Duration {
 	return time.Duration(count) * time.Hour * 24
 }
@@ -20,6 +27,10 @@ func month(count int) time.Duration {
 	return day(30) * time.Duration(count)
 }
 
+func year(count int) time.Duration {
+	return day(365) * time.Duration(count)
+}
+
 type durFunc func(int) time.Duration
 
 var regularitites = []struct {
@@ -27,37 +38,107 @@ var regularitites = []struct {
 	dur  durFunc
 }{
 	{"день", day},
+	{"дня", day},
+	{"дней", day},
+	{"дни", day},
+	{"сутки", day},
+	{"суток", day},
 	{"неделя", week},
+	{"недели", week},
+	{"недель", week},
+	{"неделю", week},
 	{"месяц", month},
+	{"месяца", month},
+	{"месяцев", month},
+	{"год", year},
+	{"года", year},
+	{"лет", year},
 	{"д", day},
+	{"дн", day},
 	{"н", week},
+	{"нед", week},
 	{"м", month},
+	{"мес", month},
+	{"г", year},
+	{"час", hour},
+	{"часа", hour},
+	{"часов", hour},
+	{"ч", hour},
+	{"минута", minute},
+	{"минуты", minute},
+	{"минут", minute},
+	{"минуту", minute},
+	{"мин", minute},
 }
 
-func nearestDuration(word string) durFunc {
-	min := levenshtein.ComputeDistance(word, regularitites[0].word)
-	minI := 0
-	for i := 1; i < len(regularitites); i++ {
-		if dist := levenshtein.ComputeDistance(word, regularitites[i].word); dist < min {
-			min = dist
-			minI = i
+// minSimilarity is the lowest similarity at which a misspelled word is still taken for a unit
+const minSimilarity = 0.6
+
+// maxSuggestions limits units suggested for an unknown word
+const maxSuggestions = 3
+
+func similarity(word, unitWord string) float64 {
+	longest := max(len([]rune(word)), len([]rune(unitWord)))
+	return 1 - float64(levenshtein.ComputeDistance(word, unitWord))/float64(longest)
+}
+
+// nearestDuration returns the unit closest to word and the similarity in [0, 1],
+// words less similar than minSimilarity to every unit are rejected with UnknownUnitError
+func nearestDuration(word string) (durFunc, float64, error) {
+	type candidate struct {
+		word       string
+		dur        durFunc
+		similarity float64
+	}
+	candidates := make([]candidate, 0, len(regularitites))
+	for _, r := range regularitites {
+		candidates = append(candidates, candidate{r.word, r.dur, similarity(word, r.word)})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.similarity > b.similarity {
+			return -1
+		}
+		if a.similarity < b.similarity {
+			return 1
+		}
+		return 0
+	})
+	if candidates[0].similarity >= minSimilarity {
+		return candidates[0].dur, candidates[0].similarity, nil
+	}
+
+	unitErr := &UnknownUnitError{Word: word}
+	seen := map[time.Duration]bool{}
+	for _, c := range candidates {
+		if len(unitErr.Suggestions) == maxSuggestions {
+			break
 		}
+		// abbreviations make poor suggestions
+		if seen[c.dur(1)] || len([]rune(c.word)) < 3 {
+			continue
+		}
+		seen[c.dur(1)] = true
+		unitErr.Suggestions = append(unitErr.Suggestions, c.word)
 	}
-	return regularitites[minI].dur
+	return nil, 0, unitErr
 }
 
+// ExtractRegularity parses regularityString and returns the duration
+// regardless of parse confidence, use Parse to get it
 func ExtractRegularity(regularityString string) (time.Duration, error) {
-	splitted := strings.Split(regularityString, " ")
-	if len(splitted) != 2 {
-		return time.Duration(0), ErrArgCount
-	}
-	count, err := strconv.ParseUint(splitted[0], 10, 64)
+	res, err := Parse(regularityString)
 	if err != nil {
-		return time.Duration(0), ErrFirstArg
+		return time.Duration(0), err
 	}
-	if count == 0 {
-		return time.Duration(0), ErrZero
+	return res.Duration, nil
+}
+
+// ParseDuration reads a duration of any length like "2 часа" or "1 день",
+// it is meant for delays and intervals other than regularities
+func ParseDuration(durationString string) (time.Duration, error) {
+	res, err := parseDuration(durationString)
+	if err != nil {
+		return time.Duration(0), err
 	}
-	dur := nearestDuration(splitted[1])
-	return dur(int(count)), nil
+	return res.Duration, nil
 }
@@ -28,3 +28,265 @@ func TestExtractRegularity(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	cases := map[string]time.Duration{
+		"каждые 2 недели":     week(2),
+		"раз в месяц":         month(1),
+		"Раз в 3 дня":         day(3),
+		"две недели":          week(2),
+		"2недели":             week(2),
+		"1.5 месяца":          day(45),
+		"1,5 месяца":          day(45),
+		"полтора месяца":      day(45),
+		"полгода":             day(365) / 2,
+		"ежедневно":           day(1),
+		"каждую неделю":       week(1),
+		"1 неделя 3 дня":      day(10),
+		"1 неделя и 3 дня":    day(10),
+		"2 раза в месяц":      day(15),
+		"двадцать один день":  day(21),
+		"  каждые   5   дней": day(5),
+		"36 часов":            hour(36),
+		"1 день 2 часа":       day(1) + hour(2),
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			res, err := Parse(key)
+			assert.NoError(t, err)
+			assert.Equal(t, value, res.Duration)
+			assert.Equal(t, 1.0, res.Confidence)
+		})
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	res, err := Parse("14")
+	assert.NoError(t, err)
+	assert.Equal(t, day(14), res.Duration)
+	assert.Equal(t, bareNumberConfidence, res.Confidence)
+
+	res, err = Parse("2 нидели")
+	assert.NoError(t, err)
+	assert.Equal(t, week(2), res.Duration)
+	assert.Less(t, res.Confidence, 1.0)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]error{
+		"":              ErrArgCount,
+		"каждые":        ErrArgCount,
+		"0 дней":        ErrZero,
+		"0 раз в год":   ErrZero,
+		"2 3 дня":       ErrNumberSequence,
+		"3 банана":      ErrUnknownUnit,
+		"5 попугаев":    ErrUnknownUnit,
+		"12 часов":      ErrTooShort,
+		"90 минут":      ErrTooShort,
+		"ежечасно":      ErrUnknownUnit,
+		"3 раза в день": ErrTooShort,
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			_, err := Parse(key)
+			assert.ErrorIs(t, err, value)
+		})
+	}
+}
+
+func TestFormatEvery(t *testing.T) {
+	cases := map[time.Duration]string{
+		day(1):   "каждый день",
+		day(14):  "каждые 2 недели",
+		day(10):  "каждые 10 дней",
+		day(21):  "каждые 3 недели",
+		day(22):  "каждые 22 дня",
+		week(1):  "каждую неделю",
+		month(5): "каждые 5 месяцев",
+		day(31):  "каждый 31 день",
+		hour(12): "каждые 12 часов",
+		hour(36): "каждые 36 часов",
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", value), func(t *testing.T) {
+			assert.Equal(t, value, FormatEvery(key))
+		})
+	}
+}
+
+func TestUnknownUnitSuggestions(t *testing.T) {
+	_, err := Parse("2 неделюшечки")
+	var unitErr *UnknownUnitError
+	assert.ErrorAs(t, err, &unitErr)
+	assert.Equal(t, "неделюшечки", unitErr.Word)
+	assert.NotEmpty(t, unitErr.Suggestions)
+	assert.LessOrEqual(t, len(unitErr.Suggestions), maxSuggestions)
+	assert.Equal(t, "недели", unitErr.Suggestions[0])
+}
+
+func TestParseFutureDate(t *testing.T) {
+	now := time.Date(2024, time.October, 19, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"25.10":      time.Date(2024, time.October, 25, 0, 0, 0, 0, time.UTC),
+		"до 25.10":   time.Date(2024, time.October, 25, 0, 0, 0, 0, time.UTC),
+		"19.10":      time.Date(2024, time.October, 19, 0, 0, 0, 0, time.UTC),
+		"01.02":      time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+		"5/1":        time.Date(2025, time.January, 5, 0, 0, 0, 0, time.UTC),
+		"3.11.2025":  time.Date(2025, time.November, 3, 0, 0, 0, 0, time.UTC),
+		"по 3.11.25": time.Date(2025, time.November, 3, 0, 0, 0, 0, time.UTC),
+		"15 ноября":  time.Date(2024, time.November, 15, 0, 0, 0, 0, time.UTC),
+		"1 мая 2026": time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC),
+		"29.02.2028": time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			res, err := ParseFutureDate(key, now)
+			assert.NoError(t, err)
+			assert.Equal(t, value, res)
+		})
+	}
+
+	for _, bad := range []string{"", "завтра", "31.02", "25.13", "25", "25.10 26.10", "30 февраля", "15 брюмера",
+		// the next 29 february is in a common year
+		"29.02", "29.02.2025"} {
+		t.Run(fmt.Sprintf("test bad %s", bad), func(t *testing.T) {
+			_, err := ParseFutureDate(bad, now)
+			assert.ErrorIs(t, err, ErrBadDate)
+		})
+	}
+}
+
+func TestParsePastDate(t *testing.T) {
+	now := time.Date(2024, time.October, 19, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"сейчас":         now,
+		"вчера":          now.AddDate(0, 0, -1),
+		"позавчера":      now.AddDate(0, 0, -2),
+		"3 дня назад":    now.AddDate(0, 0, -3),
+		"неделю назад":   now.AddDate(0, 0, -7),
+		"2 часа назад":   now.Add(-2 * time.Hour),
+		"12.10":          time.Date(2024, time.October, 12, 0, 0, 0, 0, time.UTC),
+		"25.12":          time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC),
+		"12.10.2023":     time.Date(2023, time.October, 12, 0, 0, 0, 0, time.UTC),
+		"вчера в 18:30":  time.Date(2024, time.October, 18, 18, 30, 0, 0, time.UTC),
+		"9:05":           time.Date(2024, time.October, 19, 9, 5, 0, 0, time.UTC),
+		"12.10 в 7:00":   time.Date(2024, time.October, 12, 7, 0, 0, 0, time.UTC),
+		"Позавчера 8:15": time.Date(2024, time.October, 17, 8, 15, 0, 0, time.UTC),
+		"12 октября":     time.Date(2024, time.October, 12, 0, 0, 0, 0, time.UTC),
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			res, err := ParsePastDate(key, now)
+			assert.NoError(t, err)
+			assert.Equal(t, value, res)
+		})
+	}
+
+	for _, bad := range []string{"", "завтра", "31.02", "20:00", "25.10.2024", "3 банана назад", "вчера позавчера"} {
+		t.Run(fmt.Sprintf("test bad %s", bad), func(t *testing.T) {
+			_, err := ParsePastDate(bad, now)
+			assert.ErrorIs(t, err, ErrBadDate)
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	cases := map[string]time.Duration{
+		"8":     8 * time.Hour,
+		"9:05":  9*time.Hour + 5*time.Minute,
+		"22:30": 22*time.Hour + 30*time.Minute,
+		"0":     0,
+		"24":    24 * time.Hour,
+		"24:00": 24 * time.Hour,
+	}
+	for key, value := range cases {
+		res, ok := ParseClock(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, value, res, key)
+	}
+	for _, bad := range []string{"", "24:30", "25", "-1", "8:5", "8:60", "вечер"} {
+		_, ok := ParseClock(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestParseSeason(t *testing.T) {
+	cases := map[string][]Window{
+		"апрель-октябрь":           {{time.April, 1, time.October, 31}},
+		"с апреля по октябрь":      {{time.April, 1, time.October, 31}},
+		"15.04 — 15.10":            {{time.April, 15, time.October, 15}},
+		"Ноябрь-февраль":           {{time.November, 1, time.February, 29}},
+		"апрель, октябрь":          {{time.April, 1, time.April, 30}, {time.October, 1, time.October, 31}},
+		"в марте и в сентябре":     {{time.March, 1, time.March, 31}, {time.September, 1, time.September, 30}},
+		"с 1 декабря по 15 января": {{time.December, 1, time.January, 15}},
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			res, err := ParseSeason(key)
+			assert.NoError(t, err)
+			assert.Equal(t, value, res)
+		})
+	}
+
+	for _, bad := range []string{"", "летом", "31.04-01.05", "апрель май июнь", "13.13"} {
+		t.Run(fmt.Sprintf("test bad %s", bad), func(t *testing.T) {
+			_, err := ParseSeason(bad)
+			assert.ErrorIs(t, err, ErrBadSeason)
+		})
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	windows := []Window{{time.April, 1, time.October, 31}, {time.December, 1, time.January, 15}}
+	stored := FormatWindows(windows)
+	assert.Equal(t, "04-01:10-31,12-01:01-15", stored)
+	res, err := ParseWindows(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, windows, res)
+
+	res, err = ParseWindows("")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	for _, bad := range []string{"13-40:02-31", "04-01:02-30", "00-01:01-15", "04-01", "04-01:10-31x", "апрель"} {
+		t.Run(fmt.Sprintf("test bad %s", bad), func(t *testing.T) {
+			_, err := ParseWindows(bad)
+			assert.ErrorIs(t, err, ErrBadSeason)
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	summer := Window{time.April, 1, time.October, 31}
+	assert.True(t, summer.Contains(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, summer.Contains(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)))
+	winter := Window{time.December, 1, time.January, 15}
+	assert.True(t, winter.Contains(time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, winter.Contains(time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), winter.Opening(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseWeekday(t *testing.T) {
+	cases := map[string]time.Weekday{
+		"пн":            time.Monday,
+		"понедельникам": time.Monday,
+		"Среда":         time.Wednesday,
+		"пятницу":       time.Friday,
+		"вс":            time.Sunday,
+		"воскресеньям":  time.Sunday,
+	}
+	for key, value := range cases {
+		t.Run(fmt.Sprintf("test %s", key), func(t *testing.T) {
+			res, ok := ParseWeekday(key)
+			assert.True(t, ok)
+			assert.Equal(t, value, res)
+		})
+	}
+
+	for _, bad := range []string{"", "по", "в", "день", "неделю"} {
+		t.Run(fmt.Sprintf("test bad %s", bad), func(t *testing.T) {
+			_, ok := ParseWeekday(bad)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package regularity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a part of every year from the first day to the last one inclusive,
+// it wraps around new year when To is before From
+type Window struct {
+	FromMonth time.Month
+	FromDay   int
+	ToMonth   time.Month
+	ToDay     int
+}
+
+// after compares days of the year
+func after(month time.Month, day int, otherMonth time.Month, otherDay int) bool {
+	return month > otherMonth || (month == otherMonth && day > otherDay)
+}
+
+func (w Window) Contains(t time.Time) bool {
+	t = t.UTC()
+	month, day := t.Month(), t.Day()
+	startsBefore := !after(w.FromMonth, w.FromDay, month, day)
+	endsAfter := !after(month, day, w.ToMonth, w.ToDay)
+	if !after(w.FromMonth, w.FromDay, w.ToMonth, w.ToDay) {
+		return startsBefore && endsAfter
+	}
+	return startsBefore || endsAfter
+}
+
+// Opening returns the first day of the window after t
+func (w Window) Opening(t time.Time) time.Time {
+	t = t.UTC()
+	open := time.Date(t.Year(), w.FromMonth, w.FromDay, 0, 0, 0, 0, time.UTC)
+	if !open.After(t) {
+		open = open.AddDate(1, 0, 0)
+	}
+	return open
+}
+
+// monthPrefixes match any form of a month name: "апрель", "апреля", "апреле"
+var monthPrefixes = map[string]time.Month{
+	"янв": time.January,
+	"фев": time.February,
+	"мар": time.March,
+	"апр": time.April,
+	"май": time.May,
+	"мая": time.May,
+	"мае": time.May,
+	"июн": time.June,
+	"июл": time.July,
+	"авг": time.August,
+	"сен": time.September,
+	"окт": time.October,
+	"ноя": time.November,
+	"дек": time.December,
+}
+
+var seasonPrepositions = map[string]bool{
+	"с":  true,
+	"со": true,
+	"по": true,
+	"до": true,
+	"в":  true,
+}
+
+func monthWord(word string) (time.Month, bool) {
+	runes := []rune(word)
+	if len(runes) < 3 {
+		return 0, false
+	}
+	month, ok := monthPrefixes[string(runes[:3])]
+	return month, ok
+}
+
+// daysIn returns the number of days in month of a leap year, so 29.02 is accepted
+func daysIn(month time.Month) int {
+	return time.Date(2024, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func validDay(month time.Month, day int) bool {
+	return month >= time.January && month <= time.December && day >= 1 && day <= daysIn(month)
+}
+
+// parseBound reads a month or "15.04", months start on the first day and end on the last one
+func parseBound(word string, end bool) (time.Month, int, error) {
+	if month, ok := monthWord(word); ok {
+		if end {
+			return month, daysIn(month), nil
+		}
+		return month, 1, nil
+	}
+	day, month, ok := strings.Cut(word, ".")
+	if !ok {
+		return 0, 0, ErrBadSeason
+	}
+	d, err := strconv.Atoi(day)
+	if err != nil {
+		return 0, 0, ErrBadSeason
+	}
+	m, err := strconv.Atoi(month)
+	if err != nil || !validDay(time.Month(m), d) {
+		return 0, 0, ErrBadSeason
+	}
+	return time.Month(m), d, nil
+}
+
+// joinDays turns "1 декабря" into "1.12"
+func joinDays(words []string) []string {
+	var res []string
+	for i := 0; i < len(words); i++ {
+		if _, err := strconv.Atoi(words[i]); err == nil && i+1 < len(words) {
+			if month, ok := monthWord(words[i+1]); ok {
+				res = append(res, words[i]+"."+strconv.Itoa(int(month)))
+				i++
+				continue
+			}
+		}
+		res = append(res, words[i])
+	}
+	return res
+}
+
+func parseWindow(words []string) (Window, error) {
+	words = joinDays(words)
+	if len(words) != 1 && len(words) != 2 {
+		return Window{}, ErrBadSeason
+	}
+	var w Window
+	var err error
+	w.FromMonth, w.FromDay, err = parseBound(words[0], false)
+	if err != nil {
+		return Window{}, err
+	}
+	w.ToMonth, w.ToDay, err = parseBound(words[len(words)-1], true)
+	if err != nil {
+		return Window{}, err
+	}
+	return w, nil
+}
+
+// ParseSeason understands windows like "апрель-октябрь", "с апреля по октябрь",
+// "15.04-15.10", "1 декабря - 15 января", "ноябрь-март" and several of them: "апрель, октябрь"
+func ParseSeason(s string) ([]Window, error) {
+	s = strings.ToLower(s)
+	for _, dash := range []string{"—", "–", "-"} {
+		s = strings.ReplaceAll(s, dash, " ")
+	}
+	s = strings.ReplaceAll(s, " и ", ",")
+	var res []Window
+	for _, part := range strings.Split(s, ",") {
+		var words []string
+		for _, w := range strings.Fields(part) {
+			if !seasonPrepositions[w] {
+				words = append(words, w)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		w, err := parseWindow(words)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	if len(res) == 0 {
+		return nil, ErrBadSeason
+	}
+	return res, nil
+}
+
+// FormatWindows stores windows as "04-01:10-31,12-01:01-15"
+func FormatWindows(windows []Window) string {
+	parts := make([]string, 0, len(windows))
+	for _, w := range windows {
+		parts = append(parts, fmt.Sprintf("%02d-%02d:%02d-%02d", w.FromMonth, w.FromDay, w.ToMonth, w.ToDay))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseWindows reads windows written by FormatWindows, empty string means no windows
+func ParseWindows(s string) ([]Window, error) {
+	var res []Window
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		var w Window
+		var rest string
+		n, _ := fmt.Sscanf(part, "%02d-%02d:%02d-%02d%s", &w.FromMonth, &w.FromDay, &w.ToMonth, &w.ToDay, &rest)
+		if n != 4 || !validDay(w.FromMonth, w.FromDay) || !validDay(w.ToMonth, w.ToDay) {
+			return nil, ErrBadSeason
+		}
+		res = append(res, w)
+	}
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package regularity
+
+import (
+	"strings"
+	"time"
+)
+
+// weekdayPrefixes match short names and any form of a full one: "пн", "понедельник", "по понедельникам"
+var weekdayPrefixes = map[string]time.Weekday{
+	"пн":  time.Monday,
+	"пон": time.Monday,
+	"вт":  time.Tuesday,
+	"вто": time.Tuesday,
+	"ср":  time.Wednesday,
+	"сре": time.Wednesday,
+	"чт":  time.Thursday,
+	"чет": time.Thursday,
+	"пт":  time.Friday,
+	"пят": time.Friday,
+	"сб":  time.Saturday,
+	"суб": time.Saturday,
+	"вс":  time.Sunday,
+	"вос": time.Sunday,
+}
+
+// ParseWeekday reads a day of the week from a single word
+func ParseWeekday(word string) (time.Weekday, bool) {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) == 2 {
+		day, ok := weekdayPrefixes[string(runes)]
+		return day, ok
+	}
+	if len(runes) < 3 {
+		return 0, false
+	}
+	day, ok := weekdayPrefixes[string(runes[:3])]
+	return day, ok
+}